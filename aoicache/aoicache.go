@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 )
 
 // Group 核心代码处，提供外部交互方法
@@ -14,7 +15,8 @@ type Group struct {
 	mainCache cache  //提供数据支持
 	peers     PeerPicker
 
-	loader *singleflight.Group
+	loader  *singleflight.Group
+	metrics *Metrics //为nil时不统计
 }
 
 var (
@@ -51,9 +53,11 @@ func (g *Group) Get(key string) (Data, error) {
 	if key == "" {
 		return Data{}, fmt.Errorf("key is required") //不允许空键
 	}
+	g.metrics.get(g.name)
 	get, exist := g.mainCache.get(key)
 	if exist {
 		log.Println("cache hit") //如果能够直接拿到的话说明缓存命中
+		g.metrics.hit(g.name)
 		return get, nil
 	}
 	return g.load(key)
@@ -67,14 +71,17 @@ func (g *Group) load(key string) (Data, error) {
 			if ok {                             //获取客户端成功，交给对应的客户端处理
 				peer, err := g.getFromPeer(getter, key)
 				if err == nil {
+					g.metrics.load(g.name, "peer")
 					return peer, nil
 				}
 				log.Println("[GeeCache] Failed to get from peer", err)
 			}
 		}
+		g.metrics.load(g.name, "local")
 		return g.getByGetter(key)
 	})
 	if err != nil {
+		g.metrics.loadError(g.name)
 		return Data{}, err
 	}
 	return data.(Data), nil
@@ -114,3 +121,45 @@ func (g *Group) RegisterPeers(peers PeerPicker) {
 	}
 	g.peers = peers
 }
+
+// Set 直接向本地缓存写入数据，ttl为0时永不过期
+func (g *Group) Set(key string, value []byte, ttl time.Duration) {
+	data := Data{bytes: clone(value)}
+	if ttl > 0 {
+		data.expire = time.Now().Add(ttl)
+	}
+	g.mainCache.add(key, data)
+}
+
+// Remove 删除本地缓存中的数据
+func (g *Group) Remove(key string) {
+	g.mainCache.remove(key)
+}
+
+// GetOrLoad 从本地缓存获取数据，不存在时调用load加载并以ttl写入本地缓存
+// 同一个key的并发加载只会执行一次load，其余调用等待并共享结果；load返回错误时不写入缓存
+// 与Get不同，GetOrLoad不会访问远程节点，适用于只在本机有意义的数据
+func (g *Group) GetOrLoad(key string, ttl time.Duration, load func(key string) ([]byte, error)) (Data, error) {
+	if key == "" {
+		return Data{}, fmt.Errorf("key is required")
+	}
+	g.metrics.get(g.name)
+	if data, exist := g.mainCache.get(key); exist {
+		g.metrics.hit(g.name)
+		return data, nil
+	}
+	data, err := g.loader.Do(key, func() (interface{}, error) {
+		g.metrics.load(g.name, "local")
+		bytes, err := load(key)
+		if err != nil {
+			return Data{}, err
+		}
+		g.Set(key, bytes, ttl)
+		return Data{bytes: clone(bytes)}, nil
+	})
+	if err != nil {
+		g.metrics.loadError(g.name)
+		return Data{}, err
+	}
+	return data.(Data), nil
+}
@@ -2,12 +2,15 @@ package aoicache
 
 import (
 	"AoiFramework/aoicache/consistenthash"
+	"AoiFramework/metrics"
 	"fmt"
 	"log"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestGetter(t *testing.T) {
@@ -104,3 +107,70 @@ func TestWait(t *testing.T) {
 	}
 	group.Wait()
 }
+
+func TestGetOrLoad(t *testing.T) {
+	group := NewGroup("load", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return nil, fmt.Errorf("%s not exist", key)
+	}))
+	var mu sync.Mutex
+	loads := 0
+	load := func(key string) ([]byte, error) {
+		mu.Lock()
+		loads++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		return []byte("value of " + key), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if data, err := group.GetOrLoad("k", 50*time.Millisecond, load); err != nil || data.String() != "value of k" {
+				t.Error("unexpected value", data, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if loads != 1 {
+		t.Fatal("concurrent loads should be collapsed, got", loads)
+	}
+	if data, err := group.Get("k"); err != nil || data.String() != "value of k" {
+		t.Fatal("loaded value should be cached", data, err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := group.Get("k"); err == nil {
+		t.Fatal("expired value should not be returned")
+	}
+
+	group.Set("k", []byte("v"), 0)
+	group.Remove("k")
+	if _, err := group.Get("k"); err == nil {
+		t.Fatal("removed value should not be returned")
+	}
+}
+
+func TestGroupMetrics(t *testing.T) {
+	reg := metrics.NewRegistry()
+	g := NewGroup("metrics", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	g.SetMetrics(NewMetrics(reg))
+	NewMetrics(reg).get("metrics") //同一个注册表重复注册时共用同一组数据
+	_, _ = g.Get("a")
+	_, _ = g.Get("a")
+
+	var builder strings.Builder
+	_, _ = reg.WriteTo(&builder)
+	for _, line := range []string{
+		`aoicache_gets_total{group="metrics"} 3`,
+		`aoicache_hits_total{group="metrics"} 1`,
+		`aoicache_loads_total{group="metrics",source="local"} 1`,
+	} {
+		if !strings.Contains(builder.String(), line) {
+			t.Errorf("metrics output should contain %q", line)
+		}
+	}
+}
@@ -29,7 +29,18 @@ func (c *cache) get(key string) (value Data, exist bool) {
 		return
 	}
 	if v, ok := c.lru.Get(key); ok {
-		return v.(Data), ok
+		if data := v.(Data); !data.expired() {
+			return data, ok
+		}
+		c.lru.Remove(key) //过期数据在读取时删除
 	}
 	return
 }
+
+func (c *cache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lru != nil {
+		c.lru.Remove(key)
+	}
+}
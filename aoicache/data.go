@@ -1,10 +1,18 @@
 package aoicache
 
+import "time"
+
 //存储数据
 
 // Data 保存底层数据
 type Data struct {
-	bytes []byte
+	bytes  []byte
+	expire time.Time //过期时间，为零值时永不过期
+}
+
+//expired 数据是否已经过期
+func (d Data) expired() bool {
+	return !d.expire.IsZero() && time.Now().After(d.expire)
 }
 
 //Len 返回数据长度
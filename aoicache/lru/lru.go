@@ -91,6 +91,19 @@ func (c *Cache) Add(key string, value Value) {
 	}
 }
 
+// Remove 删除指定的键，键不存在时不做任何操作
+func (c *Cache) Remove(key string) {
+	if ele, ok := c.cache[key]; ok {
+		c.ll.Remove(ele)
+		etr := ele.Value.(*entry)
+		delete(c.cache, key)
+		c.nBytes -= int64(etr.value.Len() + len(key))
+		if c.OnEvicted != nil {
+			c.OnEvicted(key, etr.value)
+		}
+	}
+}
+
 func (c *Cache) Len() int {
 	return c.ll.Len()
 }
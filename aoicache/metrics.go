@@ -0,0 +1,60 @@
+package aoicache
+
+import "AoiFramework/metrics"
+
+// Metrics 缓存指标，多个Group可以共用，通过标签group区分
+type Metrics struct {
+	getsTotal       *metrics.Counter
+	hitsTotal       *metrics.Counter
+	loadsTotal      *metrics.Counter
+	loadErrorsTotal *metrics.Counter
+}
+
+// NewMetrics 在reg中注册缓存指标，reg为nil时使用metrics.DefaultRegistry
+// 同一个注册表多次调用返回共用同一组数据的指标，之后通过Group.SetMetrics开启统计
+func NewMetrics(reg *metrics.Registry) *Metrics {
+	if reg == nil {
+		reg = metrics.DefaultRegistry
+	}
+	return &Metrics{
+		getsTotal: reg.NewCounter("aoicache_gets_total",
+			"Total number of Group.Get calls.", "group"),
+		hitsTotal: reg.NewCounter("aoicache_hits_total",
+			"Total number of local cache hits.", "group"),
+		loadsTotal: reg.NewCounter("aoicache_loads_total",
+			"Total number of cache misses loaded from peers or the getter.", "group", "source"),
+		loadErrorsTotal: reg.NewCounter("aoicache_load_errors_total",
+			"Total number of failed loads.", "group"),
+	}
+}
+
+// SetMetrics 开启Group的指标统计，默认不统计，需要在使用Group之前调用
+func (g *Group) SetMetrics(m *Metrics) {
+	g.metrics = m
+}
+
+//以下方法允许m为nil，没有开启统计时直接返回
+
+func (m *Metrics) get(group string) {
+	if m != nil {
+		m.getsTotal.Inc(group)
+	}
+}
+
+func (m *Metrics) hit(group string) {
+	if m != nil {
+		m.hitsTotal.Inc(group)
+	}
+}
+
+func (m *Metrics) load(group, source string) {
+	if m != nil {
+		m.loadsTotal.Inc(group, source)
+	}
+}
+
+func (m *Metrics) loadError(group string) {
+	if m != nil {
+		m.loadErrorsTotal.Inc(group)
+	}
+}
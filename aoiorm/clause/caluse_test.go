@@ -1,7 +1,9 @@
 package clause
 
 import (
+	"AoiFramework/aoiorm/dialect"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -23,3 +25,53 @@ func TestClause_Build(t *testing.T) {
 		testSelect(t)
 	})
 }
+
+func TestClause_Quote(t *testing.T) {
+	postgres, _ := dialect.GetDialect("postgres")
+	clause := Clause{Dialect: postgres}
+	cases := []struct {
+		build func() (string, []interface{})
+		sql   string
+	}{
+		{func() (string, []interface{}) {
+			clause.Set(INSERT, "UserAccount", []string{"order", "user"})
+			clause.Set(VALUES, []interface{}{1, "Tom"})
+			return clause.Build(INSERT, VALUES)
+		}, `INSERT INTO "UserAccount" ("order","user") VALUES( ?,? )`},
+		{func() (string, []interface{}) {
+			clause.Set(SELECT, "UserAccount", []string{"order", "user"})
+			clause.Set(WHERE, "user = ?", "Tom")
+			return clause.Build(SELECT, WHERE)
+		}, `SELECT  "order","user"  FROM "UserAccount" WHERE user = ?`},
+		{func() (string, []interface{}) {
+			clause.Set(UPDATE, "UserAccount", map[string]interface{}{"order": 1})
+			return clause.Build(UPDATE)
+		}, `UPDATE "UserAccount" SET "order" = ? `},
+		{func() (string, []interface{}) {
+			clause.Set(DELETE, "UserAccount")
+			return clause.Build(DELETE)
+		}, `DELETE FROM "UserAccount"`},
+		{func() (string, []interface{}) {
+			clause.Set(COUNT, "UserAccount")
+			return clause.Build(COUNT)
+		}, `SELECT  count(*)  FROM "UserAccount"`},
+	}
+	for _, c := range cases {
+		if sql, _ := c.build(); sql != c.sql {
+			t.Fatalf("expect %s, got %s", c.sql, sql)
+		}
+	}
+}
+
+func TestClause_ResolveColumns(t *testing.T) {
+	var clause Clause
+	resolve := func(sql string) string { return strings.ReplaceAll(sql, "Name", "name") }
+	clause.Set(SELECT, "Name", []string{"name"})
+	clause.Set(WHERE, "Name = ?", "Tom")
+	clause.Set(ORDERBY, "Name DESC")
+	clause.ResolveColumns(resolve)
+	sql, vars := clause.Build(SELECT, WHERE, ORDERBY)
+	if sql != "SELECT  name  FROM Name WHERE name = ? ORDER BY name DESC" || !reflect.DeepEqual(vars, []interface{}{"Tom"}) {
+		t.Fatal("only conditions should be resolved, got", sql, vars)
+	}
+}
@@ -1,10 +1,16 @@
 package clause
 
-import "strings"
+import (
+	"AoiFramework/aoiorm/dialect"
+	"strings"
+)
 
 type Clause struct {
+	Dialect dialect.Dialect //为生成的表名与列名加上引号，为nil时不加引号
+
 	sql     map[Type]string
 	sqlVars map[Type][]interface{}
+	vars    map[Type][]interface{} //Set传入的原始参数，用于ResolveColumns重新生成子句
 }
 
 type Type int
@@ -26,13 +32,39 @@ func (c *Clause) Set(name Type, vars ...interface{}) {
 	if c.sql == nil {
 		c.sql = make(map[Type]string)
 		c.sqlVars = make(map[Type][]interface{})
+		c.vars = make(map[Type][]interface{})
 	}
+	c.vars[name] = vars
 	gen := generators[name]
-	s, args := gen(vars...) //此处必要展开，不然只有一个元素
+	s, args := gen(c.quote, vars...) //此处必要展开，不然只有一个元素
 	c.sql[name] = s
 	c.sqlVars[name] = args
 }
 
+// ResolveColumns 使用resolve改写WHERE与ORDER BY中由用户传入的条件并重新生成这两个子句
+// 表名、列名等生成的部分不会被改写
+func (c *Clause) ResolveColumns(resolve func(string) string) {
+	for _, name := range []Type{WHERE, ORDERBY} {
+		vars, ok := c.vars[name]
+		if !ok {
+			continue
+		}
+		desc, ok := vars[0].(string)
+		if !ok {
+			continue
+		}
+		c.Set(name, append([]interface{}{resolve(desc)}, vars[1:]...)...)
+	}
+}
+
+// quote 使用方言为标识符加上引号，与建表语句保持一致
+func (c *Clause) quote(identifier string) string {
+	if c.Dialect == nil {
+		return identifier
+	}
+	return c.Dialect.Quote(identifier)
+}
+
 func (c *Clause) Build(orders ...Type) (string, []interface{}) {
 	var sqls []string
 	var args []interface{}
@@ -46,6 +78,7 @@ func (c *Clause) Build(orders ...Type) (string, []interface{}) {
 	defer func() {
 		c.sql = nil
 		c.sqlVars = nil
+		c.vars = nil
 	}()
 
 	return strings.Join(sqls, " "), args
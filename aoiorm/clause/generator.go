@@ -5,7 +5,8 @@ import (
 	"strings"
 )
 
-type generator func(values ...interface{}) (string, []interface{})
+// generator 生成子句，quote用于为表名与列名加上引号
+type generator func(quote func(string) string, values ...interface{}) (string, []interface{})
 
 var generators map[Type]generator
 
@@ -22,21 +23,30 @@ func init() {
 	generators[COUNT] = _count
 }
 
-func _count(values ...interface{}) (string, []interface{}) {
-	return _select(values[0], []string{"count(*)"})
+// quoteAll 为每个列名加上引号
+func quoteAll(quote func(string) string, names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(name)
+	}
+	return quoted
+}
+
+func _count(quote func(string) string, values ...interface{}) (string, []interface{}) {
+	return fmt.Sprintf("SELECT  count(*)  FROM %s", quote(values[0].(string))), []interface{}{}
 }
 
-func _delete(values ...interface{}) (string, []interface{}) {
-	return fmt.Sprintf("DELETE FROM %s", values[0]), []interface{}{}
+func _delete(quote func(string) string, values ...interface{}) (string, []interface{}) {
+	return fmt.Sprintf("DELETE FROM %s", quote(values[0].(string))), []interface{}{}
 }
 
-func _update(values ...interface{}) (string, []interface{}) {
-	tableName := values[0]
+func _update(quote func(string) string, values ...interface{}) (string, []interface{}) {
+	tableName := quote(values[0].(string))
 	m := values[1].(map[string]interface{})
 	var keys []string
 	var args []interface{}
 	for k, v := range m {
-		keys = append(keys, k+" = ?")
+		keys = append(keys, quote(k)+" = ?")
 		args = append(args, v)
 	}
 	return fmt.Sprintf("UPDATE %s SET %s ", tableName, strings.Join(keys, ",")), args
@@ -49,27 +59,27 @@ func genBindVars(num int) string {
 	return strings.Join(args, ",")
 }
 
-func _orderBy(values ...interface{}) (string, []interface{}) {
+func _orderBy(_ func(string) string, values ...interface{}) (string, []interface{}) {
 	return fmt.Sprintf("ORDER BY %s", values[0]), []interface{}{}
 }
 
-func _where(values ...interface{}) (string, []interface{}) {
+func _where(_ func(string) string, values ...interface{}) (string, []interface{}) {
 	// WHERE $desc
 	desc, vars := values[0], values[1:]
 	return fmt.Sprintf("WHERE %s", desc), vars
 }
 
-func _limit(values ...interface{}) (string, []interface{}) {
+func _limit(_ func(string) string, values ...interface{}) (string, []interface{}) {
 	return "LIMIT ?", values
 }
 
-func _select(values ...interface{}) (string, []interface{}) {
-	var tableName = values[0].(string)
-	var fields = strings.Join(values[1].([]string), ",")
+func _select(quote func(string) string, values ...interface{}) (string, []interface{}) {
+	var tableName = quote(values[0].(string))
+	var fields = strings.Join(quoteAll(quote, values[1].([]string)), ",")
 	return fmt.Sprintf("SELECT  %s  FROM %s", fields, tableName), []interface{}{}
 }
 
-func _values(values ...interface{}) (string, []interface{}) {
+func _values(_ func(string) string, values ...interface{}) (string, []interface{}) {
 	// VALUES ($v1), ($v2), ...
 	var bindStr string
 	var sql strings.Builder
@@ -91,10 +101,10 @@ func _values(values ...interface{}) (string, []interface{}) {
 	return sql.String(), args
 }
 
-func _insert(values ...interface{}) (string, []interface{}) {
+func _insert(quote func(string) string, values ...interface{}) (string, []interface{}) {
 	//第一个参数表名，第二个参数各个字段切片
 	// INSERT INTO $tableName ($fields)
-	var tableName = values[0].(string)
-	var fields = strings.Join(values[1].([]string), ",")
+	var tableName = quote(values[0].(string))
+	var fields = strings.Join(quoteAll(quote, values[1].([]string)), ",")
 	return fmt.Sprintf("INSERT INTO %s (%v)", tableName, fields), []interface{}{}
 }
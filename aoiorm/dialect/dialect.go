@@ -4,6 +4,7 @@ import (
 	"AoiFramework/aoiorm/olog"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 //用户自己注册map
@@ -15,6 +16,26 @@ type Dialect interface {
 	DtaTypeof(typ reflect.Value) string
 	// TableExistSQL 传入表名，返回某个表是否存在
 	TableExistSQL(tableName string) (string, []interface{})
+	// Quote 为表名、列名等标识符加上引号
+	Quote(identifier string) string
+	// BindVar 返回第index个参数的占位符，index从1开始
+	BindVar(index int) string
+	// ColumnSQL 返回建表时列名之后的类型与约束
+	ColumnSQL(column Column) string
+	// ReturningSQL 返回插入后取回自增列的子句，为空时使用LastInsertId
+	ReturningSQL(column string) string
+}
+
+// Column 建表时一列的类型与约束，由schema根据结构体标签生成
+type Column struct {
+	Type          string
+	PrimaryKey    bool
+	AutoIncrement bool
+	NotNull       bool
+	Unique        bool
+	Default       string
+	HasDefault    bool
+	Constraint    string //标签中无法识别的部分，原样追加
 }
 
 func RegisterDialect(name string, dialect Dialect) error {
@@ -31,3 +52,64 @@ func GetDialect(name string) (Dialect, bool) {
 	dia, ok := dialectMap[name]
 	return dia, ok
 }
+
+// quoteWith 使用指定的引号包裹标识符，带有.的标识符会分段包裹，标识符中的引号会被转义
+func quoteWith(identifier string, quote string) string {
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
+
+// columnSQL 按 类型 自增 主键 非空 唯一 默认值 的顺序拼接列定义，autoIncrement为方言的自增写法
+func columnSQL(column Column, autoIncrement string) string {
+	parts := []string{column.Type}
+	if column.AutoIncrement && autoIncrement != "" {
+		parts = append(parts, autoIncrement)
+	}
+	if column.PrimaryKey {
+		parts = append(parts, "PRIMARY KEY")
+	}
+	if column.NotNull {
+		parts = append(parts, "NOT NULL")
+	}
+	if column.Unique {
+		parts = append(parts, "UNIQUE")
+	}
+	if column.HasDefault {
+		parts = append(parts, "DEFAULT "+column.Default)
+	}
+	if column.Constraint != "" {
+		parts = append(parts, column.Constraint)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Rebind 将sql中的 ? 占位符替换为方言使用的占位符，引号中的 ? 不会被替换
+func Rebind(d Dialect, query string) string {
+	if d == nil || d.BindVar(1) == "?" || !strings.Contains(query, "?") {
+		return query
+	}
+	var sql strings.Builder
+	sql.Grow(len(query) + 8)
+	var quote byte //当前所在的引号，为0表示不在引号中
+	index := 0
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '?':
+			index++
+			sql.WriteString(d.BindVar(index))
+			continue
+		}
+		sql.WriteByte(ch)
+	}
+	return sql.String()
+}
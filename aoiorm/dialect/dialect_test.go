@@ -0,0 +1,90 @@
+package dialect
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDtaTypeof(t *testing.T) {
+	mysql, _ := GetDialect("mysql")
+	postgres, _ := GetDialect("postgres")
+	cases := []struct {
+		value           interface{}
+		mysql, postgres string
+	}{
+		{true, "boolean", "boolean"},
+		{int(1), "int", "integer"},
+		{int64(1), "bigint", "bigint"},
+		{uint64(1), "bigint unsigned", "numeric(20)"},
+		{float64(1), "double", "double precision"},
+		{"", "varchar(255)", "text"},
+		{[]byte{}, "longblob", "bytea"},
+		{time.Time{}, "datetime", "timestamptz"},
+	}
+	for _, c := range cases {
+		value := reflect.ValueOf(c.value)
+		if got := mysql.DtaTypeof(value); got != c.mysql {
+			t.Fatalf("mysql type of %T: expect %s, got %s", c.value, c.mysql, got)
+		}
+		if got := postgres.DtaTypeof(value); got != c.postgres {
+			t.Fatalf("postgres type of %T: expect %s, got %s", c.value, c.postgres, got)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	sqlite, _ := GetDialect("sqlite3")
+	mysql, _ := GetDialect("mysql")
+	postgres, _ := GetDialect("pgx")
+	if got := sqlite.Quote("User"); got != `"User"` {
+		t.Fatal("unexpected sqlite quote", got)
+	}
+	if got := mysql.Quote("db.my`table"); got != "`db`.`my``table`" {
+		t.Fatal("unexpected mysql quote", got)
+	}
+	if got := postgres.Quote(`public.User`); got != `"public"."User"` {
+		t.Fatal("unexpected postgres quote", got)
+	}
+}
+
+func TestRebind(t *testing.T) {
+	sqlite, _ := GetDialect("sqlite3")
+	postgres, _ := GetDialect("postgres")
+	query := "SELECT * FROM User WHERE Name = ? AND Note <> '?' AND Age > ? LIMIT ?"
+	if got := Rebind(sqlite, query); got != query {
+		t.Fatal("sqlite should keep ? placeholders, got", got)
+	}
+	expect := "SELECT * FROM User WHERE Name = $1 AND Note <> '?' AND Age > $2 LIMIT $3"
+	if got := Rebind(postgres, query); got != expect {
+		t.Fatalf("expect %s, got %s", expect, got)
+	}
+	sql, args := postgres.TableExistSQL("User")
+	if Rebind(postgres, sql) != "SELECT tablename FROM pg_catalog.pg_tables WHERE schemaname = current_schema() AND tablename = $1" ||
+		!reflect.DeepEqual(args, []interface{}{"User"}) {
+		t.Fatal("unexpected table exist sql", sql, args)
+	}
+}
+
+func TestColumnSQL(t *testing.T) {
+	sqlite, _ := GetDialect("sqlite3")
+	mysql, _ := GetDialect("mysql")
+	postgres, _ := GetDialect("postgres")
+	id := Column{Type: "bigint", PrimaryKey: true, AutoIncrement: true}
+	if got := sqlite.ColumnSQL(id); got != "integer PRIMARY KEY AUTOINCREMENT" {
+		t.Fatal("unexpected sqlite column", got)
+	}
+	if got := mysql.ColumnSQL(id); got != "bigint AUTO_INCREMENT PRIMARY KEY" {
+		t.Fatal("unexpected mysql column", got)
+	}
+	if got := postgres.ColumnSQL(id); got != "bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY" {
+		t.Fatal("unexpected postgres column", got)
+	}
+	name := Column{Type: "varchar(64)", NotNull: true, Unique: true, Default: "''", HasDefault: true}
+	if got := mysql.ColumnSQL(name); got != "varchar(64) NOT NULL UNIQUE DEFAULT ''" {
+		t.Fatal("unexpected column constraints", got)
+	}
+	if sqlite.ReturningSQL("ID") != "" || postgres.ReturningSQL("ID") != `RETURNING "ID"` {
+		t.Fatal("unexpected returning sql")
+	}
+}
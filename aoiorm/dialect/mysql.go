@@ -0,0 +1,70 @@
+package dialect
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+type MysqlDialect struct{}
+
+func (m *MysqlDialect) DtaTypeof(typ reflect.Value) string {
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int8:
+		return "tinyint"
+	case reflect.Int16:
+		return "smallint"
+	case reflect.Int, reflect.Int32:
+		return "int"
+	case reflect.Int64:
+		return "bigint"
+	case reflect.Uint8:
+		return "tinyint unsigned"
+	case reflect.Uint16:
+		return "smallint unsigned"
+	case reflect.Uint, reflect.Uint32, reflect.Uintptr:
+		return "int unsigned"
+	case reflect.Uint64:
+		return "bigint unsigned"
+	case reflect.Float32:
+		return "float"
+	case reflect.Float64:
+		return "double"
+	case reflect.String:
+		return "varchar(255)" //text不能直接作为主键或索引
+	case reflect.Array, reflect.Slice:
+		return "longblob"
+	case reflect.Struct:
+		if _, ok := typ.Interface().(time.Time); ok {
+			return "datetime"
+		}
+	}
+	panic(fmt.Sprintf("invalid sql type %s (%s)", typ.Type().Name(), typ.Kind()))
+}
+
+func (m *MysqlDialect) TableExistSQL(tableName string) (string, []interface{}) {
+	args := []interface{}{tableName}
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", args
+}
+
+func (m *MysqlDialect) Quote(identifier string) string {
+	return quoteWith(identifier, "`")
+}
+
+func (m *MysqlDialect) BindVar(int) string {
+	return "?"
+}
+
+func (m *MysqlDialect) ColumnSQL(column Column) string {
+	return columnSQL(column, "AUTO_INCREMENT")
+}
+
+func (m *MysqlDialect) ReturningSQL(string) string {
+	return ""
+}
+
+func init() {
+	RegisterDialect("mysql", &MysqlDialect{})
+}
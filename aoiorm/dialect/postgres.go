@@ -0,0 +1,67 @@
+package dialect
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+type PostgresDialect struct{}
+
+func (p *PostgresDialect) DtaTypeof(typ reflect.Value) string {
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "smallint"
+	case reflect.Int, reflect.Int32, reflect.Uint16:
+		return "integer"
+	case reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uintptr:
+		return "bigint"
+	case reflect.Uint64:
+		return "numeric(20)" //bigint无法表示全部uint64
+	case reflect.Float32:
+		return "real"
+	case reflect.Float64:
+		return "double precision"
+	case reflect.String:
+		return "text"
+	case reflect.Array, reflect.Slice:
+		return "bytea"
+	case reflect.Struct:
+		if _, ok := typ.Interface().(time.Time); ok {
+			return "timestamptz"
+		}
+	}
+	panic(fmt.Sprintf("invalid sql type %s (%s)", typ.Type().Name(), typ.Kind()))
+}
+
+func (p *PostgresDialect) TableExistSQL(tableName string) (string, []interface{}) {
+	args := []interface{}{tableName}
+	return "SELECT tablename FROM pg_catalog.pg_tables WHERE schemaname = current_schema() AND tablename = ?", args
+}
+
+func (p *PostgresDialect) Quote(identifier string) string {
+	return quoteWith(identifier, `"`)
+}
+
+// BindVar postgres使用 $1、$2 作为占位符
+func (p *PostgresDialect) BindVar(index int) string {
+	return "$" + strconv.Itoa(index)
+}
+
+func (p *PostgresDialect) ColumnSQL(column Column) string {
+	return columnSQL(column, "GENERATED BY DEFAULT AS IDENTITY")
+}
+
+// ReturningSQL postgres的驱动不支持LastInsertId，通过RETURNING取回自增列
+func (p *PostgresDialect) ReturningSQL(column string) string {
+	return "RETURNING " + p.Quote(column)
+}
+
+func init() {
+	//lib/pq与pgx注册的驱动名不同
+	RegisterDialect("postgres", &PostgresDialect{})
+	RegisterDialect("pgx", &PostgresDialect{})
+}
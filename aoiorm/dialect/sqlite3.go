@@ -36,6 +36,28 @@ func (s *SqliteDialect) TableExistSQL(tableName string) (string, []interface{})
 	args := []interface{}{tableName}
 	return "SELECT name FROM sqlite_master WHERE type='table' and name = ?", args
 }
+
+func (s *SqliteDialect) Quote(identifier string) string {
+	return quoteWith(identifier, `"`)
+}
+
+func (s *SqliteDialect) BindVar(int) string {
+	return "?"
+}
+
+// ColumnSQL sqlite只允许 integer PRIMARY KEY 自增
+func (s *SqliteDialect) ColumnSQL(column Column) string {
+	if column.AutoIncrement {
+		column.Type, column.PrimaryKey = "integer", false
+		return columnSQL(column, "PRIMARY KEY AUTOINCREMENT")
+	}
+	return columnSQL(column, "")
+}
+
+func (s *SqliteDialect) ReturningSQL(string) string {
+	return ""
+}
+
 func init() {
 	RegisterDialect("sqlite3", &SqliteDialect{})
 }
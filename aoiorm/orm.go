@@ -3,6 +3,7 @@ package aoiorm
 import (
 	"AoiFramework/aoiorm/dialect"
 	"AoiFramework/aoiorm/olog"
+	"AoiFramework/aoiorm/schema"
 	"AoiFramework/aoiorm/session"
 	"database/sql"
 	"fmt"
@@ -13,6 +14,8 @@ import (
 type Engine struct {
 	db      *sql.DB
 	dialect dialect.Dialect
+	// NamingStrategy 表名与列名的命名规则，默认为snake_case，表名为复数形式
+	NamingStrategy schema.Namer
 }
 
 func NewEngine(driver, source string) (e *Engine, err error) {
@@ -32,7 +35,7 @@ func NewEngine(driver, source string) (e *Engine, err error) {
 		return
 	}
 	olog.Info("connect database success!!")
-	return &Engine{db: db, dialect: getDialect}, nil
+	return &Engine{db: db, dialect: getDialect, NamingStrategy: schema.DefaultNamingStrategy}, nil
 }
 
 func (engine *Engine) Close() {
@@ -46,7 +49,7 @@ func (engine *Engine) Close() {
 }
 
 func (engine *Engine) NewSession() *session.Session {
-	return session.New(engine.db, engine.dialect)
+	return session.New(engine.db, engine.dialect).Naming(engine.NamingStrategy)
 }
 
 type TxFunc func(session *session.Session) (result interface{}, err error)
@@ -93,17 +96,22 @@ func (engine *Engine) Migrate(value interface{}) error {
 			olog.Infof("table %s doesn't exist", s.RefTable().Name)
 			return nil, s.CreateTable()
 		}
-		//检查表名与当前模型字段
+		//检查表名与当前模型字段，表名与列名按方言加上引号，列定义与CreateTable一致
 		table := s.RefTable()
-		rows, _ := s.Raw(fmt.Sprintf("SELECT * FROM %s LIMIT 1", table.Name)).QueryRows()
+		quote := engine.dialect.Quote
+		rows, err := s.Raw(fmt.Sprintf("SELECT * FROM %s LIMIT 1", quote(table.Name))).QueryRows()
+		if err != nil {
+			return
+		}
 		colums, _ := rows.Columns()
+		_ = rows.Close()
 		addCols := difference(table.FieldNames, colums)
 		delCols := difference(colums, table.FieldNames)
 
 		olog.Infof("added cols %v, deleted cols %v", addCols, delCols)
 		for _, col := range addCols {
 			field := table.GetField(col)
-			sqlStr := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table.Name, col, field.Type)
+			sqlStr := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", quote(table.Name), quote(col), engine.dialect.ColumnSQL(field.Column()))
 			if _, err = s.Raw(sqlStr).Exec(); err != nil {
 				return
 			}
@@ -113,13 +121,16 @@ func (engine *Engine) Migrate(value interface{}) error {
 			return
 		}
 
-		tmp := "tmp_" + table.Name
-
-		fieldStr := strings.Join(table.FieldNames, ", ")
+		tmp := quote("tmp_" + table.Name)
+		fields := make([]string, len(table.FieldNames))
+		for i, name := range table.FieldNames {
+			fields[i] = quote(name)
+		}
+		fieldStr := strings.Join(fields, ", ")
 
-		s.Raw(fmt.Sprintf("CREATE TABLE %s AS SELECT %s from %s;", tmp, fieldStr, table.Name))
-		s.Raw(fmt.Sprintf("DROP TABLE %s;", table.Name))
-		s.Raw(fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", tmp, table.Name))
+		s.Raw(fmt.Sprintf("CREATE TABLE %s AS SELECT %s from %s;", tmp, fieldStr, quote(table.Name)))
+		s.Raw(fmt.Sprintf("DROP TABLE %s;", quote(table.Name)))
+		s.Raw(fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", tmp, quote(table.Name)))
 
 		_, err = s.Exec()
 		return
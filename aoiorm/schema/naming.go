@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Namer 将结构体名与字段名转换为表名与列名
+type Namer interface {
+	TableName(name string) string
+	ColumnName(name string) string
+}
+
+// Tabler 实现该接口的模型使用TableName的返回值作为表名，不再经过Namer转换
+type Tabler interface {
+	TableName() string
+}
+
+// NamingStrategy 默认的命名规则，UserAccount 的表名为 user_accounts，字段 UserID 的列名为 user_id
+type NamingStrategy struct {
+	TablePrefix   string //表名前缀，如 t_
+	SingularTable bool   //表名不使用复数形式
+}
+
+// DefaultNamingStrategy 没有指定命名规则时使用
+var DefaultNamingStrategy Namer = NamingStrategy{}
+
+func (ns NamingStrategy) TableName(name string) string {
+	name = toSnakeCase(name)
+	if !ns.SingularTable {
+		name = plural(name)
+	}
+	return ns.TablePrefix + name
+}
+
+func (ns NamingStrategy) ColumnName(name string) string {
+	return toSnakeCase(name)
+}
+
+// toSnakeCase 驼峰转下划线，连续的大写字母视为一个单词，如 HTTPServer -> http_server
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// plural 英文单词的简单复数形式
+func plural(word string) string {
+	switch {
+	case word == "":
+		return word
+	case strings.HasSuffix(word, "s") || strings.HasSuffix(word, "x") || strings.HasSuffix(word, "z") ||
+		strings.HasSuffix(word, "ch") || strings.HasSuffix(word, "sh"):
+		return word + "es"
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return word[:len(word)-1] + "ies"
+	}
+	return word + "s"
+}
@@ -2,37 +2,149 @@ package schema
 
 import (
 	"AoiFramework/aoiorm/dialect"
+	"fmt"
 	"go/ast"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // Field 代表某一列
 type Field struct {
-	Name string
-	Type string
-	Tag  string //约束条件
+	Name          string //列名，默认由Namer根据字段名生成，可以通过 column:name 指定
+	FieldName     string //结构体中的字段名
+	Type          string
+	Tag           string //原始的aoiorm标签
+	PrimaryKey    bool
+	AutoIncrement bool
+	NotNull       bool
+	Unique        bool
+	Default       string
+	HasDefault    bool //default:'' 与没有默认值需要区分
+	Size          int  //字符串的最大长度，大于0时类型为varchar(size)
+	Constraint    string
+	Index         []int //在结构体中的下标，用于FieldByIndex快速取值
 }
 
-// Schema 代表一张表
+// Column 转换为方言渲染列定义使用的结构
+func (f *Field) Column() dialect.Column {
+	return dialect.Column{
+		Type:          f.Type,
+		PrimaryKey:    f.PrimaryKey,
+		AutoIncrement: f.AutoIncrement,
+		NotNull:       f.NotNull,
+		Unique:        f.Unique,
+		Default:       f.Default,
+		HasDefault:    f.HasDefault,
+		Constraint:    f.Constraint,
+	}
+}
+
+// Schema 代表一张表，Parse返回的字段信息来自缓存，只读
 type Schema struct {
-	Module     interface{}
-	Name       string
-	Fields     []*Field
-	FieldNames []string
-	fieldMap   map[string]*Field
+	Module        interface{}
+	Name          string
+	Fields        []*Field
+	FieldNames    []string          //列名
+	PrimaryKey    *Field            //第一个主键列，没有时为nil
+	AutoIncrement *Field            //自增列，没有时为nil
+	fieldMap      map[string]*Field //列名 -> 字段
+	goFieldMap    map[string]*Field //结构体字段名 -> 字段
 }
 
 func (schema *Schema) GetField(name string) *Field {
 	return schema.fieldMap[name]
 }
 
-func Parse(dest interface{}, d dialect.Dialect) *Schema {
-	//先获取传入参数的类型数据
+// ColumnName 返回列名，name可以是列名也可以是结构体字段名，都不是时原样返回
+func (schema *Schema) ColumnName(name string) string {
+	if _, ok := schema.fieldMap[name]; ok {
+		return name
+	}
+	if field, ok := schema.goFieldMap[name]; ok {
+		return field.Name
+	}
+	return name
+}
+
+// ResolveColumns 将sql片段中出现的结构体字段名替换为列名，引号中的内容不会被替换
+// 使得 Where("UserName = ?") 与 OrderBy("Age DESC") 在任何命名规则下都可以使用字段名
+// 只用于用户传入的条件片段，生成的完整sql中的表名、函数名等也可能与字段名相同
+func (schema *Schema) ResolveColumns(sql string) string {
+	var sb strings.Builder
+	var quote byte
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case isIdentStart(ch):
+			j := i + 1
+			for j < len(sql) && (isIdentStart(sql[j]) || sql[j] >= '0' && sql[j] <= '9') {
+				j++
+			}
+			sb.WriteString(schema.ColumnName(sql[i:j]))
+			i = j
+			continue
+		}
+		sb.WriteByte(ch)
+		i++
+	}
+	return sb.String()
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+// cacheKey 解析结果只与模型类型、方言和命名规则有关
+type cacheKey struct {
+	typ     reflect.Type
+	dialect dialect.Dialect
+	namer   Namer
+}
+
+// schemaCache 缓存解析结果，cacheKey -> *Schema
+var schemaCache sync.Map
+
+// Parse 解析模型，namer为空时使用DefaultNamingStrategy，模型实现Tabler时使用其返回的表名
+// 解析结果按类型、方言与命名规则缓存，返回的是缓存的浅拷贝，只有Module为dest，
+// Fields、FieldNames与其中的*Field由所有调用方共享，只能读取，不能修改
+func Parse(dest interface{}, d dialect.Dialect, namer ...Namer) *Schema {
+	naming := DefaultNamingStrategy
+	if len(namer) > 0 && namer[0] != nil {
+		naming = namer[0]
+	}
 	modelType := reflect.Indirect(reflect.ValueOf(dest)).Type()
+	//命名规则不可比较时无法作为map的键，不使用缓存
+	if !reflect.TypeOf(naming).Comparable() {
+		schema := parse(modelType, d, naming)
+		schema.Module = dest
+		return schema
+	}
+	key := cacheKey{typ: modelType, dialect: d, namer: naming}
+	cached, ok := schemaCache.Load(key)
+	if !ok {
+		cached, _ = schemaCache.LoadOrStore(key, parse(modelType, d, naming))
+	}
+	schema := *cached.(*Schema)
+	schema.Module = dest
+	return &schema
+}
+
+func parse(modelType reflect.Type, d dialect.Dialect, naming Namer) *Schema {
 	schema := &Schema{
-		Module:   dest,
-		Name:     modelType.Name(),
-		fieldMap: make(map[string]*Field),
+		Name:       naming.TableName(modelType.Name()),
+		fieldMap:   make(map[string]*Field),
+		goFieldMap: make(map[string]*Field),
+	}
+	if tabler, ok := reflect.New(modelType).Interface().(Tabler); ok {
+		schema.Name = tabler.TableName()
 	}
 	//根据类型进行生成
 	for i := 0; i < modelType.NumField(); i++ {
@@ -40,27 +152,96 @@ func Parse(dest interface{}, d dialect.Dialect) *Schema {
 		//获取各个字段，要求P不是嵌套结构体并且是暴露的
 		if !p.Anonymous && ast.IsExported(p.Name) {
 			field := &Field{
-				Name: p.Name,
-				Type: d.DtaTypeof(reflect.Indirect(reflect.New(p.Type))),
+				Name:      naming.ColumnName(p.Name),
+				FieldName: p.Name,
+				Index:     p.Index,
 			}
 			if v, ok := p.Tag.Lookup("aoiorm"); ok {
 				field.Tag = v
+				parseTag(field, v)
+			}
+			field.Type = d.DtaTypeof(reflect.Indirect(reflect.New(p.Type)))
+			if field.Size > 0 && p.Type.Kind() == reflect.String {
+				field.Type = fmt.Sprintf("varchar(%d)", field.Size)
+			}
+			if field.PrimaryKey && schema.PrimaryKey == nil {
+				schema.PrimaryKey = field
+			}
+			if field.AutoIncrement {
+				schema.AutoIncrement = field
 			}
 			schema.Fields = append(schema.Fields, field)
 			schema.FieldNames = append(schema.FieldNames, field.Name)
-			schema.fieldMap[p.Name] = field
+			schema.fieldMap[field.Name] = field
+			schema.goFieldMap[field.FieldName] = field
 		}
 	}
 	return schema
 }
 
+// parseTag 解析标签，各项以;分隔，键不区分大小写与空格，如
+// aoiorm:"column:user_name;primaryKey;autoIncrement;not null;default:0;unique;size:64"
+// 无法识别的项原样作为约束追加到列定义中
+func parseTag(field *Field, tag string) {
+	var constraints []string
+	for _, item := range strings.Split(tag, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, value := item, ""
+		if i := strings.Index(item, ":"); i >= 0 {
+			key, value = item[:i], strings.TrimSpace(item[i+1:])
+		}
+		switch strings.ToLower(strings.ReplaceAll(key, " ", "")) {
+		case "column":
+			field.Name = value
+		case "primarykey":
+			field.PrimaryKey = true
+		case "autoincrement":
+			field.AutoIncrement = true
+		case "notnull":
+			field.NotNull = true
+		case "unique":
+			field.Unique = true
+		case "default":
+			field.Default, field.HasDefault = value, true
+		case "size":
+			if size, err := strconv.Atoi(value); err == nil {
+				field.Size = size
+				continue
+			}
+			constraints = append(constraints, item)
+		default:
+			constraints = append(constraints, item)
+		}
+	}
+	field.Constraint = strings.Join(constraints, " ")
+}
+
 func (schema *Schema) RecordValues(dest interface{}) []interface{} {
 	//获取目的dst的value对象
 	indirect := reflect.Indirect(reflect.ValueOf(dest))
 	var args []interface{}
 	//根据保存的内容获取各个元素
 	for _, field := range schema.Fields { //从保存的模式中读取数据
-		args = append(args, indirect.FieldByName(field.Name).Interface())
+		args = append(args, indirect.FieldByIndex(field.Index).Interface())
 	}
 	return args
 }
+
+// InsertValues 返回插入时使用的列名与值，自增列为零值时省略，由数据库生成
+func (schema *Schema) InsertValues(dest interface{}) ([]string, []interface{}) {
+	indirect := reflect.Indirect(reflect.ValueOf(dest))
+	var names []string
+	var args []interface{}
+	for _, field := range schema.Fields {
+		value := indirect.FieldByIndex(field.Index)
+		if field.AutoIncrement && value.IsZero() {
+			continue
+		}
+		names = append(names, field.Name)
+		args = append(args, value.Interface())
+	}
+	return names, args
+}
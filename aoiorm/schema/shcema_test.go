@@ -14,10 +14,103 @@ var TestDial, _ = dialect.GetDialect("sqlite3")
 
 func TestParse(t *testing.T) {
 	schema := Parse(&User{}, TestDial)
-	if schema.Name != "User" || len(schema.Fields) != 2 {
+	if schema.Name != "users" || len(schema.Fields) != 2 {
 		t.Fatal("failed to parse User struct")
 	}
-	if schema.GetField("Name").Tag != "PRIMARY KEY" {
+	if schema.GetField("name").Tag != "PRIMARY KEY" {
 		t.Fatal("failed to parse primary key")
 	}
 }
+
+type Account struct {
+	ID       int64  `aoiorm:"primaryKey;autoIncrement"`
+	UserName string `aoiorm:"column:user_name;not null;unique;size:64"`
+	Balance  int    `aoiorm:"default:0;CHECK (Balance >= 0)"`
+}
+
+func TestParseTag(t *testing.T) {
+	schema := Parse(&Account{}, TestDial)
+	if schema.PrimaryKey == nil || schema.PrimaryKey.Name != "id" || schema.AutoIncrement != schema.PrimaryKey {
+		t.Fatal("failed to parse auto increment primary key")
+	}
+	name := schema.GetField("user_name")
+	if name == nil || name.FieldName != "UserName" || !name.NotNull || !name.Unique || name.Type != "varchar(64)" {
+		t.Fatal("failed to parse column options", name)
+	}
+	balance := schema.GetField("balance")
+	if !balance.HasDefault || balance.Default != "0" || balance.Constraint != "CHECK (Balance >= 0)" {
+		t.Fatal("failed to parse default and raw constraint", balance)
+	}
+	names, values := schema.InsertValues(&Account{UserName: "Tom", Balance: 3})
+	if len(names) != 2 || names[0] != "user_name" || values[1] != 3 {
+		t.Fatal("zero auto increment column should be omitted", names, values)
+	}
+}
+
+type UserAccount struct {
+	UserID     int
+	HTTPServer string
+}
+
+type Category struct {
+	Name string
+}
+
+func (Category) TableName() string {
+	return "t_category"
+}
+
+func TestNamingStrategy(t *testing.T) {
+	schema := Parse(&UserAccount{}, TestDial)
+	if schema.Name != "user_accounts" || schema.FieldNames[0] != "user_id" || schema.FieldNames[1] != "http_server" {
+		t.Fatal("unexpected default names", schema.Name, schema.FieldNames)
+	}
+	schema = Parse(&UserAccount{}, TestDial, NamingStrategy{TablePrefix: "t_", SingularTable: true})
+	if schema.Name != "t_user_account" {
+		t.Fatal("unexpected prefixed table name", schema.Name)
+	}
+	if Parse(&Category{}, TestDial).Name != "t_category" {
+		t.Fatal("TableName method should override naming strategy")
+	}
+	for word, expect := range map[string]string{"box": "boxes", "category": "categories", "day": "days", "user": "users"} {
+		if got := plural(word); got != expect {
+			t.Fatalf("plural of %s: expect %s, got %s", word, expect, got)
+		}
+	}
+	sql := schema.ResolveColumns("UserID = ? AND HTTPServer <> 'UserID' ORDER BY user_id")
+	if sql != "user_id = ? AND http_server <> 'UserID' ORDER BY user_id" {
+		t.Fatal("unexpected resolved sql", sql)
+	}
+}
+
+func TestParseCache(t *testing.T) {
+	a, b := &User{Name: "Tom"}, &User{Name: "Sam"}
+	sa, sb := Parse(a, TestDial), Parse(b, TestDial)
+	if sa.Fields[0] != sb.Fields[0] || sa.Module != a || sb.Module != b {
+		t.Fatal("schema of the same type should be cached with its own module")
+	}
+	if Parse(a, TestDial, NamingStrategy{SingularTable: true}).Name != "user" {
+		t.Fatal("naming strategy should be part of the cache key")
+	}
+	mysql, _ := dialect.GetDialect("mysql")
+	if Parse(&Account{}, mysql).GetField("balance").Type != "int" {
+		t.Fatal("dialect should be part of the cache key")
+	}
+
+	done := make(chan *Schema)
+	for i := 0; i < 8; i++ {
+		go func() { done <- Parse(&UserAccount{}, TestDial) }()
+	}
+	first := <-done
+	for i := 1; i < 8; i++ {
+		if s := <-done; s.Fields[0] != first.Fields[0] {
+			t.Fatal("concurrent parse should share the cached schema")
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Parse(&User{}, TestDial)
+	}
+}
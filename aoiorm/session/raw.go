@@ -24,6 +24,7 @@ type Session struct {
 	args []interface{}
 
 	dia      dialect.Dialect
+	namer    schema.Namer
 	refTable *schema.Schema
 
 	cla clause.Clause
@@ -36,9 +37,17 @@ func New(db *sql.DB, dialect dialect.Dialect) *Session {
 	return &Session{
 		db:  db,
 		dia: dialect,
+		cla: clause.Clause{Dialect: dialect},
 	}
 }
 
+// Naming 设置解析模型时使用的命名规则
+func (s *Session) Naming(namer schema.Namer) *Session {
+	s.namer = namer
+	s.refTable = nil
+	return s
+}
+
 func (session *Session) Clear() {
 	session.sql.Reset()
 	session.args = []interface{}{}
@@ -58,11 +67,17 @@ func (s *Session) Raw(sql string, values ...interface{}) *Session {
 	return s
 }
 
+// SQL 返回按方言替换占位符后的sql
+func (s *Session) SQL() string {
+	return dialect.Rebind(s.dia, s.sql.String())
+}
+
 func (s *Session) Exec() (result sql.Result, err error) {
 	defer s.Clear()
-	olog.Info(s.sql.String(), s.args)
+	query := s.SQL()
+	olog.Info(query, s.args)
 
-	if result, err = s.DB().Exec(s.sql.String(), s.args...); err != nil {
+	if result, err = s.DB().Exec(query, s.args...); err != nil {
 		olog.Error(err)
 	}
 	return
@@ -70,18 +85,20 @@ func (s *Session) Exec() (result sql.Result, err error) {
 
 func (s *Session) QueryRow() *sql.Row {
 	defer s.Clear()
-	olog.Info(s.sql.String(), s.args)
-	return s.DB().QueryRow(s.sql.String(), s.args...)
+	query := s.SQL()
+	olog.Info(query, s.args)
+	return s.DB().QueryRow(query, s.args...)
 }
 
 func (s *Session) QueryRows() (*sql.Rows, error) {
 	defer s.Clear()
-	olog.Info(s.sql.String(), s.args)
-	query, err := s.DB().Query(s.sql.String(), s.args...)
+	query := s.SQL()
+	olog.Info(query, s.args)
+	rows, err := s.DB().Query(query, s.args...)
 	if err != nil {
 		olog.Error(err)
 	}
-	return query, err
+	return rows, err
 }
 
 func (s *Session) First(value interface{}) error {
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"AoiFramework/aoiorm/clause"
 	"AoiFramework/aoiorm/dialect"
 	"database/sql"
 	_ "github.com/mattn/go-sqlite3"
@@ -43,3 +44,16 @@ func TestSession_QueryRows(t *testing.T) {
 		t.Fatal("failed to query db", err)
 	}
 }
+
+func TestSession_Rebind(t *testing.T) {
+	postgres, _ := dialect.GetDialect("postgres")
+	s := New(nil, postgres)
+	s.Limit(3).Where("Name = ?", "Tom")
+	s.Model(&User{})
+	s.cla.Set(clause.SELECT, s.RefTable().Name, s.RefTable().FieldNames)
+	s.cla.ResolveColumns(s.RefTable().ResolveColumns)
+	query, vars := s.cla.Build(clause.SELECT, clause.WHERE, clause.LIMIT)
+	if got := s.Raw(query, vars...).SQL(); got != `SELECT  "name","age"  FROM "users" WHERE name = $1 LIMIT $2 ` {
+		t.Fatal("unexpected sql", got)
+	}
+}
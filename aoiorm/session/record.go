@@ -2,23 +2,61 @@ package session
 
 import (
 	"AoiFramework/aoiorm/clause"
+	"AoiFramework/aoiorm/schema"
 	"reflect"
 )
 
 //增删查改
 
-// Insert 增加方法，参数为各个结构体指针
+// Insert 增加方法，参数为各个结构体指针，记录通过 INSERT ... VALUES 批量插入
+// 自增列为零值时由数据库生成并写回结构体。自增列是否为零值相同的连续记录作为一组插入：
+// 方言支持RETURNING时一组记录仍然批量插入并一次取回，否则只能通过LastInsertId逐条取回，
+// 此时每条记录执行一条INSERT，大批量插入会明显变慢；不需要写回自增值时(传入的不是指针)仍然批量插入
 func (s *Session) Insert(values ...interface{}) (int64, error) {
-	var args []interface{}
+	if len(values) == 0 {
+		return 0, nil
+	}
 	for _, value := range values {
 		s.CallMethod(BeforeInsert, value)
-		table := s.Model(value).refTable //获取表数据，利用获得的field反射提取字段数据
-		s.cla.Set(clause.INSERT, table.Name, table.FieldNames)
-		recordValues := table.RecordValues(value)
-		args = append(args, recordValues) //提取出的数据设置到args中
 	}
-	//开始拼凑sql
-	s.cla.Set(clause.VALUES, args...)
+	table := s.Model(values[0]).refTable //获取表数据，利用获得的field反射提取字段数据
+	auto := table.AutoIncrement
+	if auto == nil {
+		return s.insertBatch(table, values)
+	}
+	autoValue := func(value interface{}) reflect.Value {
+		return reflect.Indirect(reflect.ValueOf(value)).FieldByIndex(auto.Index)
+	}
+	var affected int64
+	for start := 0; start < len(values); {
+		//部分记录省略自增列时列不一致，按自增列是否为零值分组插入
+		generated, fill := autoValue(values[start]).IsZero(), false
+		end := start
+		for ; end < len(values) && autoValue(values[end]).IsZero() == generated; end++ {
+			fill = fill || autoValue(values[end]).CanSet()
+		}
+		var n int64
+		var err error
+		switch {
+		case !generated || !fill:
+			n, err = s.insertBatch(table, values[start:end])
+		case s.dia.ReturningSQL(auto.Name) != "":
+			n, err = s.insertReturning(table, values[start:end])
+		default:
+			n, err = s.insertEach(table, values[start:end])
+		}
+		affected += n
+		if err != nil {
+			return affected, err
+		}
+		start = end
+	}
+	return affected, nil
+}
+
+// insertBatch 使用一条INSERT插入所有记录，不写回自增值
+func (s *Session) insertBatch(table *schema.Schema, values []interface{}) (int64, error) {
+	s.buildInsert(table, values)
 	build, i := s.cla.Build(clause.INSERT, clause.VALUES)
 	exec, err := s.Raw(build, i...).Exec()
 	if err != nil {
@@ -27,6 +65,80 @@ func (s *Session) Insert(values ...interface{}) (int64, error) {
 	return exec.RowsAffected()
 }
 
+// buildInsert 设置INSERT与VALUES子句，所有记录需要使用相同的列
+func (s *Session) buildInsert(table *schema.Schema, values []interface{}) {
+	var args []interface{}
+	var names []string
+	for _, value := range values {
+		var recordValues []interface{}
+		names, recordValues = table.InsertValues(value)
+		args = append(args, recordValues) //提取出的数据设置到args中
+	}
+	s.cla.Set(clause.INSERT, table.Name, names)
+	s.cla.Set(clause.VALUES, args...)
+}
+
+// insertReturning 批量插入，通过RETURNING按插入顺序取回所有自增值
+func (s *Session) insertReturning(table *schema.Schema, values []interface{}) (int64, error) {
+	auto := table.AutoIncrement
+	s.buildInsert(table, values)
+	build, vars := s.cla.Build(clause.INSERT, clause.VALUES)
+	rows, err := s.Raw(build, vars...).Raw(s.dia.ReturningSQL(auto.Name)).QueryRows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var affected int64
+	for ; affected < int64(len(values)) && rows.Next(); affected++ {
+		dest := reflect.Indirect(reflect.ValueOf(values[affected])).FieldByIndex(auto.Index)
+		target := reflect.New(dest.Type())
+		if err := rows.Scan(target.Interface()); err != nil {
+			return affected, err
+		}
+		if dest.CanSet() {
+			dest.Set(target.Elem())
+		}
+	}
+	return affected, rows.Err()
+}
+
+// insertEach 逐条插入，自增列为零值时通过LastInsertId写回生成的值，只用于不支持RETURNING的方言
+func (s *Session) insertEach(table *schema.Schema, values []interface{}) (int64, error) {
+	auto := table.AutoIncrement
+	var affected int64
+	for _, value := range values {
+		names, args := table.InsertValues(value)
+		s.cla.Set(clause.INSERT, table.Name, names)
+		s.cla.Set(clause.VALUES, args)
+		sql, vars := s.cla.Build(clause.INSERT, clause.VALUES)
+		result, err := s.Raw(sql, vars...).Exec()
+		if err != nil {
+			return affected, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return affected, err
+		}
+		affected += n
+
+		dest := reflect.Indirect(reflect.ValueOf(value)).FieldByIndex(auto.Index)
+		if len(names) == len(table.Fields) || !dest.CanSet() { //自增列没有被省略或无法写回
+			continue
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return affected, err
+		}
+		switch dest.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			dest.SetInt(id)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			dest.SetUint(uint64(id))
+		}
+	}
+	return affected, nil
+}
+
 func (s *Session) Find(values interface{}) error {
 	//传入的是一个切片地址
 	dstSlice := reflect.Indirect(reflect.ValueOf(values))
@@ -36,6 +148,7 @@ func (s *Session) Find(values interface{}) error {
 
 	//传入参数，构筑sql语句
 	s.cla.Set(clause.SELECT, table.Name, table.FieldNames)
+	s.cla.ResolveColumns(table.ResolveColumns) //条件中的字段名替换为列名
 	sql, vars := s.cla.Build(clause.SELECT, clause.WHERE, clause.ORDERBY, clause.LIMIT)
 	//传入参数进行查询
 	rows, err := s.Raw(sql, vars...).QueryRows()
@@ -47,8 +160,8 @@ func (s *Session) Find(values interface{}) error {
 		dst := reflect.New(dstType).Elem()
 		var values []interface{}
 		//根据得到的列名称进行选择
-		for _, name := range table.FieldNames {
-			values = append(values, dst.FieldByName(name).Addr().Interface())
+		for _, field := range table.Fields {
+			values = append(values, dst.FieldByIndex(field.Index).Addr().Interface())
 		}
 		//得到每个字段关联的实例对象
 		if err := rows.Scan(values...); err != nil {
@@ -73,8 +186,15 @@ func (s *Session) Update(kv ...interface{}) (int64, error) {
 			m[kv[i].(string)] = kv[i+1]
 		}
 	}
+	//键可以是字段名，统一转换为列名
+	columns := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		columns[s.refTable.ColumnName(k)] = v
+	}
+	m = columns
 	//开始拼凑sql
 	s.cla.Set(clause.UPDATE, s.refTable.Name, m)
+	s.cla.ResolveColumns(s.refTable.ResolveColumns)
 	build, i := s.cla.Build(clause.UPDATE, clause.WHERE)
 	exec, err := s.Raw(build, i...).Exec()
 	if err != nil {
@@ -87,6 +207,7 @@ func (s *Session) Update(kv ...interface{}) (int64, error) {
 func (s *Session) Delete() (int64, error) {
 	//只要表名
 	s.cla.Set(clause.DELETE, s.refTable.Name)
+	s.cla.ResolveColumns(s.refTable.ResolveColumns)
 	build, i := s.cla.Build(clause.DELETE, clause.WHERE)
 	exec, err := s.Raw(build, i...).Exec()
 	if err != nil {
@@ -96,6 +217,7 @@ func (s *Session) Delete() (int64, error) {
 }
 func (s *Session) Count() (int64, error) {
 	s.cla.Set(clause.COUNT, s.refTable.Name)
+	s.cla.ResolveColumns(s.refTable.ResolveColumns)
 	build, i := s.cla.Build(clause.COUNT, clause.WHERE)
 	row := s.Raw(build, i...).QueryRow()
 	var tmp int64
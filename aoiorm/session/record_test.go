@@ -1,6 +1,17 @@
 package session
 
-import "testing"
+import (
+	"AoiFramework/aoiorm/dialect"
+	"AoiFramework/aoiorm/schema"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
 
 type User struct {
 	Name string `aoiorm:"PRIMARY KEY"`
@@ -84,3 +95,225 @@ func TestSession_DeleteAndCount(t *testing.T) {
 		t.Fatal("failed to delete or count")
 	}
 }
+
+type Wallet struct {
+	ID   int64  `aoiorm:"primaryKey;autoIncrement"`
+	Name string `aoiorm:"column:user_name;not null;size:64"`
+}
+
+func TestSession_InsertAutoIncrement(t *testing.T) {
+	s := NewSession().Model(&Wallet{})
+	if err := s.DropTable(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	a, b := &Wallet{Name: "Tom"}, &Wallet{Name: "Sam"}
+	if affected, err := s.Insert(a, b); err != nil || affected != 2 {
+		t.Fatal("failed to insert wallets", affected, err)
+	}
+	if a.ID != 1 || b.ID != 2 {
+		t.Fatal("auto increment ids should be filled back", a.ID, b.ID)
+	}
+	var wallets []Wallet
+	if err := s.Where("user_name = ?", "Sam").Find(&wallets); err != nil || len(wallets) != 1 || wallets[0].ID != 2 {
+		t.Fatal("failed to query by column name", wallets, err)
+	}
+}
+
+// returningDialect 让sqlite通过RETURNING取回自增值，sqlite 3.35之后支持
+type returningDialect struct{ dialect.Dialect }
+
+func (d returningDialect) ReturningSQL(column string) string {
+	return "RETURNING " + d.Quote(column)
+}
+
+func TestSession_InsertBatch(t *testing.T) {
+	s := New(TestDB, returningDialect{TestDial}).Model(&Wallet{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	a, b := &Wallet{Name: "Tom"}, &Wallet{Name: "Sam"}
+	if affected, err := s.Insert(a, b); err != nil || affected != 2 || a.ID != 1 || b.ID != 2 {
+		t.Fatal("ids should be filled back by RETURNING", affected, a.ID, b.ID, err)
+	}
+
+	//不需要写回时批量插入，部分记录指定了自增值时分组插入
+	s = NewSession().Model(&Wallet{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	if affected, err := s.Insert(Wallet{Name: "Jack"}, Wallet{Name: "Rose"}); err != nil || affected != 2 {
+		t.Fatal("failed to insert values without filling ids", affected, err)
+	}
+	c, d := &Wallet{ID: 10, Name: "Bob"}, &Wallet{Name: "Amy"}
+	if affected, err := s.Insert(c, d); err != nil || affected != 2 || d.ID != 11 {
+		t.Fatal("mixed records should be inserted in groups", affected, d.ID, err)
+	}
+}
+
+func TestSession_Naming(t *testing.T) {
+	s := NewSession().Naming(schema.NamingStrategy{TablePrefix: "t_"}).Model(&User{})
+	if s.RefTable().Name != "t_users" {
+		t.Fatal("naming strategy should be applied, got", s.RefTable().Name)
+	}
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = s.Insert(user1, user2)
+	if affected, err := s.Where("Name = ?", "Tom").Update("Age", 20); err != nil || affected != 1 {
+		t.Fatal("failed to update with field names", affected, err)
+	}
+	var users []User
+	if err := s.Where("Age = ?", 20).OrderBy("Name DESC").Find(&users); err != nil || len(users) != 1 || users[0].Name != "Tom" {
+		t.Fatal("failed to query with field names", users, err)
+	}
+}
+
+func BenchmarkSession_Insert(b *testing.B) {
+	s := NewSession().Model(&User{})
+	users := make([]interface{}, 100)
+	for i := range users {
+		users[i] = &User{Name: "user" + strconv.Itoa(i), Age: i}
+	}
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		//清空表不计入耗时，只统计批量插入
+		b.StopTimer()
+		_, _ = s.Delete()
+		b.StartTimer()
+		if _, err := s.Insert(users...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSession_Find(b *testing.B) {
+	s := NewSession().Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	users := make([]interface{}, 100)
+	for i := range users {
+		users[i] = &User{Name: "user" + strconv.Itoa(i), Age: i}
+	}
+	if _, err := s.Insert(users...); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var result []User
+		if err := s.Find(&result); err != nil || len(result) != 100 {
+			b.Fatal("failed to find users", err)
+		}
+	}
+}
+
+// recordDriver 只记录执行的sql，与lib/pq一样Exec的结果不支持LastInsertId，
+// Query按VALUES中的记录数返回递增的自增值
+type recordDriver struct{}
+
+var recordedSQL []string
+
+func init() {
+	sql.Register("aoiorm-record", recordDriver{})
+}
+
+func (recordDriver) Open(string) (driver.Conn, error) { return &recordConn{}, nil }
+
+type recordConn struct{ id int64 }
+
+func (c *recordConn) Prepare(query string) (driver.Stmt, error) { return &recordStmt{c, query}, nil }
+func (c *recordConn) Close() error                              { return nil }
+func (c *recordConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type recordStmt struct {
+	conn  *recordConn
+	query string
+}
+
+func (s *recordStmt) Close() error  { return nil }
+func (s *recordStmt) NumInput() int { return -1 }
+
+func (s *recordStmt) Exec([]driver.Value) (driver.Result, error) {
+	recordedSQL = append(recordedSQL, s.query)
+	return driver.RowsAffected(strings.Count(s.query, "( ")), nil
+}
+
+func (s *recordStmt) Query([]driver.Value) (driver.Rows, error) {
+	recordedSQL = append(recordedSQL, s.query)
+	rows := &recordRows{}
+	for i := strings.Count(s.query, "( "); i > 0; i-- {
+		s.conn.id++
+		rows.ids = append(rows.ids, s.conn.id)
+	}
+	return rows, nil
+}
+
+type recordRows struct{ ids []int64 }
+
+func (r *recordRows) Columns() []string { return []string{"id"} }
+func (r *recordRows) Close() error      { return nil }
+
+func (r *recordRows) Next(dest []driver.Value) error {
+	if len(r.ids) == 0 {
+		return io.EOF
+	}
+	dest[0], r.ids = r.ids[0], r.ids[1:]
+	return nil
+}
+
+func TestSession_InsertMixedPostgres(t *testing.T) {
+	db, _ := sql.Open("aoiorm-record", "")
+	defer db.Close()
+	postgres, _ := dialect.GetDialect("postgres")
+	recordedSQL = nil
+	a, b, c, d := &Wallet{Name: "Tom"}, &Wallet{Name: "Sam"}, &Wallet{ID: 10, Name: "Bob"}, &Wallet{Name: "Amy"}
+	affected, err := New(db, postgres).Model(&Wallet{}).Insert(a, b, c, d)
+	if err != nil || affected != 4 {
+		t.Fatal("failed to insert mixed records", affected, err)
+	}
+	if a.ID != 1 || b.ID != 2 || c.ID != 10 || d.ID != 3 {
+		t.Fatal("ids should be filled back by RETURNING", a.ID, b.ID, c.ID, d.ID)
+	}
+	expect := []string{
+		`INSERT INTO "wallets" ("user_name") VALUES( $1 ),( $2 ) RETURNING "id" `,
+		`INSERT INTO "wallets" ("id","user_name") VALUES( $1,$2 ) `,
+		`INSERT INTO "wallets" ("user_name") VALUES( $1 ) RETURNING "id" `,
+	}
+	if !reflect.DeepEqual(recordedSQL, expect) {
+		t.Fatalf("unexpected sql\n%q", recordedSQL)
+	}
+}
+
+type Ticket struct {
+	Order int    `aoiorm:"column:order;primaryKey"`
+	User  string `aoiorm:"column:user"`
+}
+
+func (Ticket) TableName() string { return "UserAccount" }
+
+func TestSession_QuoteKeywords(t *testing.T) {
+	s := NewSession().Model(&Ticket{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Insert(&Ticket{1, "Tom"}, &Ticket{2, "Sam"}); err != nil {
+		t.Fatal("failed to insert into keyword columns", err)
+	}
+	if affected, err := s.Where(`"order" = ?`, 1).Update("User", "Jack"); err != nil || affected != 1 {
+		t.Fatal("failed to update keyword column", affected, err)
+	}
+	var tickets []Ticket
+	if err := s.Find(&tickets); err != nil || len(tickets) != 2 || tickets[0].User != "Jack" {
+		t.Fatal("failed to query keyword columns", tickets, err)
+	}
+	if count, err := s.Count(); err != nil || count != 2 {
+		t.Fatal("failed to count", count, err)
+	}
+	if affected, err := s.Delete(); err != nil || affected != 2 {
+		t.Fatal("failed to delete", affected, err)
+	}
+}
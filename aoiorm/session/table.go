@@ -4,16 +4,13 @@ import (
 	"AoiFramework/aoiorm/olog"
 	"AoiFramework/aoiorm/schema"
 	"fmt"
-	"reflect"
 	"strings"
 )
 
 // Model 操作的是一张表
 func (s *Session) Model(value interface{}) *Session {
-	//为空或者操作的元素不相等
-	if s.refTable == nil || reflect.ValueOf(value) != reflect.ValueOf(s.refTable.Module) {
-		s.refTable = schema.Parse(value, s.dia)
-	}
+	//解析结果按类型缓存，这里只会在第一次使用某个类型时反射
+	s.refTable = schema.Parse(value, s.dia, s.namer)
 	return s
 }
 
@@ -32,17 +29,17 @@ func (s *Session) CreateTable() error {
 	table := s.refTable
 	var columns []string //拼凑
 	for _, field := range table.Fields {
-		columns = append(columns, fmt.Sprintf("%s %s %s", field.Name, field.Type, field.Tag))
+		columns = append(columns, fmt.Sprintf("%s %s", s.dia.Quote(field.Name), s.dia.ColumnSQL(field.Column())))
 	}
 	//创建sql
 	desc := strings.Join(columns, ",")
-	_, err := s.Raw(fmt.Sprintf("Create table %s (%s);", table.Name, desc)).Exec()
+	_, err := s.Raw(fmt.Sprintf("Create table %s (%s);", s.dia.Quote(table.Name), desc)).Exec()
 	return err
 }
 
 //删除表
 func (s *Session) DropTable() (err error) {
-	_, err = s.Raw(fmt.Sprintf("drop table if exists %s", s.refTable.Name)).Exec()
+	_, err = s.Raw(fmt.Sprintf("drop table if exists %s", s.dia.Quote(s.refTable.Name))).Exec()
 	return
 }
 
@@ -67,14 +67,38 @@ func TestEngine_Migrate(t *testing.T) {
 	engine := OpenDB(t)
 	defer engine.Close()
 	s := engine.NewSession()
-	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
-	_, _ = s.Raw("CREATE TABLE User(Name text PRIMARY KEY, XXX integer);").Exec()
-	_, _ = s.Raw("INSERT INTO User(`Name`) values (?), (?)", "Tom", "Sam").Exec()
+	_, _ = s.Raw("DROP TABLE IF EXISTS users;").Exec()
+	_, _ = s.Raw("CREATE TABLE users(name text PRIMARY KEY, xxx integer);").Exec()
+	_, _ = s.Raw("INSERT INTO users(`name`) values (?), (?)", "Tom", "Sam").Exec()
 	engine.Migrate(&User{})
 
-	rows, _ := s.Raw("SELECT * FROM User").QueryRows()
+	rows, _ := s.Raw("SELECT * FROM users").QueryRows()
 	columns, _ := rows.Columns()
-	if !reflect.DeepEqual(columns, []string{"Name", "Age"}) {
+	if !reflect.DeepEqual(columns, []string{"name", "age"}) {
 		t.Fatal("Failed to migrate table User, got columns", columns)
 	}
 }
+
+type Order struct {
+	Name  string `aoiorm:"primaryKey"`
+	Group int    `aoiorm:"column:group;not null;default:0"`
+}
+
+func TestEngine_MigrateQuote(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession()
+	_, _ = s.Raw(`DROP TABLE IF EXISTS "orders";`).Exec()
+	_, _ = s.Raw(`CREATE TABLE "orders"("name" text PRIMARY KEY, "select" integer);`).Exec()
+	_, _ = s.Raw(`INSERT INTO "orders"("name") values (?)`, "Tom").Exec()
+	if err := engine.Migrate(&Order{}); err != nil {
+		t.Fatal("failed to migrate table with keyword columns", err)
+	}
+
+	rows, _ := s.Raw(`SELECT * FROM "orders"`).QueryRows()
+	columns, _ := rows.Columns()
+	_ = rows.Close()
+	if !reflect.DeepEqual(columns, []string{"name", "group"}) {
+		t.Fatal("Failed to migrate table orders, got columns", columns)
+	}
+}
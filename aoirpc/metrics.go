@@ -0,0 +1,46 @@
+package aoirpc
+
+import (
+	"AoiFramework/metrics"
+	"time"
+)
+
+// Metrics 服务端调用指标
+type Metrics struct {
+	callsTotal      *metrics.Counter
+	callErrorsTotal *metrics.Counter
+	callDuration    *metrics.Histogram
+}
+
+// NewMetrics 在reg中注册服务端调用指标，reg为nil时使用metrics.DefaultRegistry
+// 同一个注册表多次调用返回共用同一组数据的指标，之后通过Server.SetMetrics开启统计
+func NewMetrics(reg *metrics.Registry) *Metrics {
+	if reg == nil {
+		reg = metrics.DefaultRegistry
+	}
+	return &Metrics{
+		callsTotal: reg.NewCounter("aoirpc_server_calls_total",
+			"Total number of handled RPC calls.", "method"),
+		callErrorsTotal: reg.NewCounter("aoirpc_server_call_errors_total",
+			"Total number of RPC calls that returned an error.", "method"),
+		callDuration: reg.NewHistogram("aoirpc_server_call_duration_seconds",
+			"RPC call latency in seconds.", metrics.DefBuckets, "method"),
+	}
+}
+
+// SetMetrics 开启服务端的调用统计，默认不统计，需要在开始处理请求之前调用
+func (server *Server) SetMetrics(m *Metrics) {
+	server.metrics = m
+}
+
+//observeCall 记录一次方法调用，m为nil时不统计
+func (m *Metrics) observeCall(serviceMethod string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.callsTotal.Inc(serviceMethod)
+	m.callDuration.Observe(time.Since(start).Seconds(), serviceMethod)
+	if err != nil {
+		m.callErrorsTotal.Inc(serviceMethod)
+	}
+}
@@ -38,6 +38,7 @@ var DefaultOption = &Option{
 
 type Server struct {
 	serviceMap sync.Map
+	metrics    *Metrics //为nil时不统计
 }
 
 func NewServer() *Server {
@@ -181,7 +182,9 @@ func (server *Server) handleReq(c codec.Codec, req *request, lock *sync.Mutex, w
 	defer close(finish)
 
 	go func() {
+		start := time.Now()
 		err := req.svc.call(req.mtype, req.argv, req.replyv)
+		server.metrics.observeCall(req.h.ServiceMethod, start, err)
 		select {
 		case <-finish:
 			close(called)
@@ -2,8 +2,12 @@ package aoiweb
 
 import (
 	"html/template"
+	"net"
 	"net/http"
 	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // HandleFunc 该类型实现了handleFunc
@@ -14,45 +18,111 @@ type Engine struct {
 	router       *router
 	*RouterGroup                //本身就作为一个routeGroup
 	groups       []*RouterGroup //存储所有的分组
+	hosts        []*hostRouter  //按host划分的路由，通过Host添加
 
 	htmlTemplates *template.Template // 添加html模板支持
 	funcMap       template.FuncMap   // 模板的渲染支持函数
+
+	trustedCIDRs    []*net.IPNet //受信任的代理地址，通过SetTrustedProxies设置
+	RemoteIPHeaders []string     //ClientIP解析客户端地址时使用的请求头，为nil时使用默认值
+
+	UseH2C bool //不使用tls时也支持HTTP/2(h2c)，适用于负载均衡之后的内部服务
+
+	//路由匹配时会忽略路径中的空白部分，以下选项用于规范请求路径，默认关闭
+	RedirectTrailingSlash bool //路径只有末尾的 / 与路由规则不同时，重定向到路由规则的形式
+	RedirectFixedPath     bool //清理多余的 /、. 与 .. 并忽略大小写后能够匹配时，重定向到修正后的路径
+	RemoveExtraSlash      bool //匹配前清理路径，不重定向
+
+	UseRawPath         bool //使用URL.RawPath匹配路由，参数中可以包含转义的 /，如 a%2Fb
+	UnescapePathValues bool //UseRawPath开启时是否对参数值进行反转义，默认开启
 }
 
 func (e *Engine) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	c := e.CreateContext(writer, request)
+	//先根据host选择路由，没有匹配的host时使用默认路由
+	router, hostPattern := e.router, ""
+	if host, params := e.matchHost(c.Host()); host != nil {
+		router, hostPattern = host.router, host.pattern
+		c.Params, c.hostParams = params, params
+	}
 	//需要根据参数判断需要执行的中间件，engine中存了所有的group
-	var middlewares []HandleFunc
+	//Engine本身的中间件对所有host生效，其余分组只对所属host的请求生效
 	for _, group := range e.groups {
+		if group != e.RouterGroup && group.host != hostPattern {
+			continue
+		}
 		if strings.HasPrefix(request.URL.Path, group.prefix) {
-			middlewares = append(middlewares, group.middlewares...)
+			c.handlers = append(c.handlers, group.middlewares...)
 		}
 	}
 	//需要开始配对
+	router.handle(c)
+}
+
+// CreateContext 创建上下文并设置处理链，不经过路由匹配，调用c.Next()依次执行handlers
+// 主要用于在没有完整路由的情况下测试中间件与处理函数
+func (e *Engine) CreateContext(writer http.ResponseWriter, request *http.Request, handlers ...HandleFunc) *Context {
 	c := newContext(writer, request)
-	c.handlers = middlewares
+	c.handlers = handlers
 	c.engine = e
-	e.router.handle(c)
+	return c
 }
 
 //New 返回空的Engine对象
 func New() *Engine {
-	e := &Engine{router: newRouter()}
+	e := &Engine{router: newRouter(), UnescapePathValues: true}
 	e.RouterGroup = &RouterGroup{
 		engine: e,
+		router: e.router,
 	}
 	e.groups = make([]*RouterGroup, 0)
 	e.groups = append(e.groups, e.RouterGroup)
 	return e
 }
 
+// Routes 返回所有已注册的路由，默认路由在前，之后按Host的添加顺序返回各host的路由
+func (e *Engine) Routes() []RouteInfo {
+	routes := append([]RouteInfo(nil), e.router.routes...)
+	for _, host := range e.hosts {
+		for _, route := range host.router.routes {
+			route.Host = host.pattern
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// Handler 返回用于http.Server的handler，开启UseH2C时同时支持HTTP/1.1与h2c
+func (e *Engine) Handler() http.Handler {
+	if !e.UseH2C {
+		return e
+	}
+	return h2c.NewHandler(e, &http2.Server{})
+}
+
 func (e *Engine) Run(address string) error {
-	return http.ListenAndServe(address, e)
+	return http.ListenAndServe(address, e.Handler())
 }
 
 func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
 	engine.funcMap = funcMap
 }
 
+//templateFuncs 中间件提供的请求级模板函数的占位实现，使模板在解析时可以引用这些函数
+//渲染时由Context.HTML替换为当前请求的实现
+var templateFuncs = template.FuncMap{
+	"csrfToken": func() string { return "" },
+	"cspNonce":  func() string { return "" },
+	"t":         func(key string, args ...interface{}) string { return key },
+}
+
 func (engine *Engine) LoadHTMLGlob(pattern string) {
-	engine.htmlTemplates = template.Must(template.New("").Funcs(engine.funcMap).ParseGlob(pattern))
+	funcMap := make(template.FuncMap, len(templateFuncs)+len(engine.funcMap))
+	for name, fn := range templateFuncs {
+		funcMap[name] = fn
+	}
+	for name, fn := range engine.funcMap { //用户注册的函数优先
+		funcMap[name] = fn
+	}
+	engine.htmlTemplates = template.Must(template.New("").Funcs(funcMap).ParseGlob(pattern))
 }
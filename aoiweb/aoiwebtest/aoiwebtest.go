@@ -0,0 +1,272 @@
+// Package aoiwebtest 提供在进程内测试aoiweb处理函数的工具，
+// 通过链式调用构造请求并对响应进行断言，无需启动监听端口
+package aoiwebtest
+
+import (
+	"AoiFramework/aoiweb"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// CreateTestContext 返回一个使用空Engine的上下文，请求默认为 GET /
+// 可以通过c.SetRequest替换请求，再调用中间件或处理函数进行单元测试；
+// 直接替换c.Request时c.Method与c.Path不会随之更新
+func CreateTestContext(w http.ResponseWriter, handlers ...aoiweb.HandleFunc) (*aoiweb.Context, *aoiweb.Engine) {
+	engine := aoiweb.New()
+	c := engine.CreateContext(w, httptest.NewRequest(http.MethodGet, "/", nil), handlers...)
+	return c, engine
+}
+
+// Client 在进程内向handler发送请求
+type Client struct {
+	t       testing.TB
+	handler http.Handler
+}
+
+// New 传入测试对象与待测试的handler，通常为*aoiweb.Engine
+func New(t testing.TB, handler http.Handler) *Client {
+	return &Client{t: t, handler: handler}
+}
+
+func (client *Client) GET(path string) *Request    { return client.Request(http.MethodGet, path) }
+func (client *Client) POST(path string) *Request   { return client.Request(http.MethodPost, path) }
+func (client *Client) PUT(path string) *Request    { return client.Request(http.MethodPut, path) }
+func (client *Client) PATCH(path string) *Request  { return client.Request(http.MethodPatch, path) }
+func (client *Client) DELETE(path string) *Request { return client.Request(http.MethodDelete, path) }
+
+// Request 构造指定方法与路径的请求
+func (client *Client) Request(method, path string) *Request {
+	return &Request{
+		client: client,
+		method: method,
+		path:   path,
+		header: make(http.Header),
+		query:  make(url.Values),
+	}
+}
+
+// Request 待发送的请求，通过链式调用设置各项参数
+type Request struct {
+	client  *Client
+	method  string
+	path    string
+	header  http.Header
+	query   url.Values
+	cookies []*http.Cookie
+	body    []byte
+	remote  string
+}
+
+// WithHeader 设置请求头
+func (r *Request) WithHeader(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// WithQuery 添加查询参数
+func (r *Request) WithQuery(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// WithCookie 添加cookie
+func (r *Request) WithCookie(name, value string) *Request {
+	r.cookies = append(r.cookies, &http.Cookie{Name: name, Value: value})
+	return r
+}
+
+// WithCookies 添加多个cookie，通常来自上一个响应
+func (r *Request) WithCookies(cookies []*http.Cookie) *Request {
+	r.cookies = append(r.cookies, cookies...)
+	return r
+}
+
+// WithBody 设置原始请求体
+func (r *Request) WithBody(contentType string, body []byte) *Request {
+	r.header.Set("Content-Type", contentType)
+	r.body = body
+	return r
+}
+
+// WithJSON 将obj编码为json作为请求体
+func (r *Request) WithJSON(obj interface{}) *Request {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		r.client.t.Fatalf("aoiwebtest: encode json body: %v", err)
+	}
+	return r.WithBody("application/json", body)
+}
+
+// WithForm 设置表单请求体
+func (r *Request) WithForm(form url.Values) *Request {
+	return r.WithBody("application/x-www-form-urlencoded", []byte(form.Encode()))
+}
+
+// WithRemoteAddr 设置客户端地址，用于测试代理相关的逻辑
+func (r *Request) WithRemoteAddr(addr string) *Request {
+	r.remote = addr
+	return r
+}
+
+// Build 构造http.Request而不发送
+func (r *Request) Build() *http.Request {
+	target := r.path
+	if len(r.query) > 0 {
+		separator := "?"
+		if strings.Contains(target, "?") {
+			separator = "&"
+		}
+		target += separator + r.query.Encode()
+	}
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+	req := httptest.NewRequest(r.method, target, body)
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	for _, cookie := range r.cookies {
+		req.AddCookie(cookie)
+	}
+	if r.remote != "" {
+		req.RemoteAddr = r.remote
+	}
+	return req
+}
+
+// Expect 发送请求并返回可以断言的响应
+func (r *Request) Expect() *Response {
+	w := httptest.NewRecorder()
+	r.client.handler.ServeHTTP(w, r.Build())
+	return &Response{t: r.client.t, ResponseRecorder: w}
+}
+
+// Response 请求的响应，断言失败时调用t.Errorf，可以继续链式断言
+type Response struct {
+	t testing.TB
+	*httptest.ResponseRecorder
+}
+
+// Status 断言响应码
+func (resp *Response) Status(code int) *Response {
+	resp.t.Helper()
+	if resp.Code != code {
+		resp.t.Errorf("aoiwebtest: expect status %d, got %d, body: %s", code, resp.Code, resp.Body.String())
+	}
+	return resp
+}
+
+// Header 断言响应头
+func (resp *Response) Header(key, value string) *Response {
+	resp.t.Helper()
+	if got := resp.ResponseRecorder.Header().Get(key); got != value {
+		resp.t.Errorf("aoiwebtest: expect header %s=%q, got %q", key, value, got)
+	}
+	return resp
+}
+
+// BodyEquals 断言响应体
+func (resp *Response) BodyEquals(body string) *Response {
+	resp.t.Helper()
+	if got := resp.Body.String(); got != body {
+		resp.t.Errorf("aoiwebtest: expect body %q, got %q", body, got)
+	}
+	return resp
+}
+
+// BodyContains 断言响应体包含指定内容
+func (resp *Response) BodyContains(s string) *Response {
+	resp.t.Helper()
+	if !strings.Contains(resp.Body.String(), s) {
+		resp.t.Errorf("aoiwebtest: expect body to contain %q, got %q", s, resp.Body.String())
+	}
+	return resp
+}
+
+// JSONPath 断言json响应中指定路径的值，路径使用点号分隔，数组使用下标，如 data.items.0.name
+// expect会先编码为json再解码，因此可以直接使用int、结构体等类型进行比较
+func (resp *Response) JSONPath(path string, expect interface{}) *Response {
+	resp.t.Helper()
+	got, err := resp.lookup(path)
+	if err != nil {
+		resp.t.Errorf("aoiwebtest: %v", err)
+		return resp
+	}
+	want, err := normalize(expect)
+	if err != nil {
+		resp.t.Errorf("aoiwebtest: encode expected value: %v", err)
+		return resp
+	}
+	if !reflect.DeepEqual(got, want) {
+		resp.t.Errorf("aoiwebtest: expect %s=%v, got %v", path, want, got)
+	}
+	return resp
+}
+
+// DecodeJSON 将响应体解码到obj
+func (resp *Response) DecodeJSON(obj interface{}) *Response {
+	resp.t.Helper()
+	if err := json.Unmarshal(resp.Body.Bytes(), obj); err != nil {
+		resp.t.Errorf("aoiwebtest: decode json body: %v", err)
+	}
+	return resp
+}
+
+// Cookie 返回响应中设置的cookie，不存在时返回nil
+func (resp *Response) Cookie(name string) *http.Cookie {
+	for _, cookie := range resp.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func (resp *Response) lookup(path string) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &value); err != nil {
+		return nil, fmt.Errorf("response is not json: %v", err)
+	}
+	if path == "" || path == "." {
+		return value, nil
+	}
+	for _, part := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			child, ok := v[part]
+			if !ok {
+				return nil, fmt.Errorf("json path %s: key %q not found", path, part)
+			}
+			value = child
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("json path %s: invalid index %q", path, part)
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("json path %s: cannot descend into %q", path, part)
+		}
+	}
+	return value, nil
+}
+
+func normalize(value interface{}) (interface{}, error) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	err = json.Unmarshal(bytes, &result)
+	return result, err
+}
@@ -0,0 +1,64 @@
+package aoiwebtest
+
+import (
+	"AoiFramework/aoiweb"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	r := aoiweb.New()
+	r.Post("/users/:name", func(c *aoiweb.Context) {
+		var body struct {
+			Age int `json:"age"`
+		}
+		if err := json.NewDecoder(c.Request.Body).Decode(&body); err != nil {
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		token, _ := c.Cookie("token")
+		c.SetHeader("X-Token", token)
+		c.JSON(http.StatusCreated, aoiweb.H{
+			"name": c.Param("name"),
+			"age":  body.Age,
+			"tags": []string{c.Query("tag")},
+		})
+	})
+
+	New(t, r).POST("/users/Tom").
+		WithQuery("tag", "admin").
+		WithCookie("token", "abc").
+		WithJSON(map[string]int{"age": 18}).
+		Expect().
+		Status(http.StatusCreated).
+		Header("Content-Type", "application/json").
+		Header("X-Token", "abc").
+		JSONPath("name", "Tom").
+		JSONPath("age", 18).
+		JSONPath("tags.0", "admin")
+}
+
+func TestCreateTestContext(t *testing.T) {
+	w := httptest.NewRecorder()
+	var order []string
+	middleware := func(c *aoiweb.Context) {
+		order = append(order, "before")
+		c.Next()
+		order = append(order, "after")
+	}
+	c, _ := CreateTestContext(w, middleware, func(c *aoiweb.Context) {
+		order = append(order, "handler")
+		c.String(http.StatusOK, "%s %s hello %s", c.Method, c.Path, c.Query("name"))
+	})
+	c.SetRequest(httptest.NewRequest("POST", "/users?name=aoi", nil))
+	c.Next()
+
+	if len(order) != 3 || order[0] != "before" || order[1] != "handler" || order[2] != "after" {
+		t.Fatal("unexpected handler order", order)
+	}
+	if w.Code != http.StatusOK || w.Body.String() != "POST /users hello aoi" {
+		t.Fatal("unexpected response", w.Code, w.Body.String())
+	}
+}
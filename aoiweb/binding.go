@@ -0,0 +1,232 @@
+package aoiweb
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// bindingSources 按顺序绑定的参数来源，标签名即来源，后绑定的来源覆盖先绑定的值
+var bindingSources = []string{"form", "query", "header", "path"}
+
+// Validator 实现该接口的请求对象会在绑定完成后调用Validate进行校验
+type Validator interface {
+	Validate() error
+}
+
+// ShouldBind 将请求绑定到obj，obj需要是结构体指针
+// 请求体为json时按json标签解码，为表单时按form标签绑定，之后依次绑定query、header与path标签指定的参数
+// 标记了 binding:"required" 的字段绑定后仍为零值时返回错误，最后调用Validator进行校验
+func (c *Context) ShouldBind(obj interface{}) error {
+	value, err := bindingTarget(obj)
+	if err != nil {
+		return err
+	}
+	if err := c.bindBody(value.Addr().Interface()); err != nil {
+		return err
+	}
+	for _, source := range bindingSources {
+		if err := bindValues(value, source, c.bindingValues(source)); err != nil {
+			return err
+		}
+	}
+	return validate(value)
+}
+
+// bindingTarget 返回obj指向的结构体，obj本身指向指针时分配对象
+func bindingTarget(obj interface{}) (reflect.Value, error) {
+	value := reflect.ValueOf(obj)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return value, errors.New("aoiweb: binding target must be a non-nil pointer")
+	}
+	value = value.Elem()
+	if value.Kind() == reflect.Ptr { //Req本身为指针类型时分配对象
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return value, errors.New("aoiweb: binding target must be a struct")
+	}
+	return value, nil
+}
+
+// validate 检查必填字段并调用Validator
+func validate(value reflect.Value) error {
+	if err := validateRequired(value); err != nil {
+		return err
+	}
+	if validator, ok := value.Addr().Interface().(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+// bindBody 请求体为json时解码到obj，空请求体不视为错误
+func (c *Context) bindBody(obj interface{}) error {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil
+	}
+	contentType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	if contentType != "application/json" && !strings.HasSuffix(contentType, "+json") {
+		return nil
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(obj); err != nil && err != io.EOF {
+		return fmt.Errorf("invalid json body: %w", err)
+	}
+	return nil
+}
+
+// bindingValues 返回指定来源的参数
+func (c *Context) bindingValues(source string) map[string][]string {
+	switch source {
+	case "form":
+		contentType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+		if contentType != "application/x-www-form-urlencoded" && contentType != "multipart/form-data" {
+			return nil
+		}
+		if contentType == "multipart/form-data" {
+			_ = c.Request.ParseMultipartForm(32 << 20)
+		} else {
+			_ = c.Request.ParseForm()
+		}
+		return c.Request.PostForm
+	case "query":
+		return c.Request.URL.Query()
+	case "header":
+		return c.Request.Header
+	case "path":
+		values := make(map[string][]string, len(c.Params))
+		for key, value := range c.Params {
+			values[key] = []string{value}
+		}
+		return values
+	}
+	return nil
+}
+
+// bindValues 按标签将参数设置到结构体字段，匿名嵌入的结构体会递归处理
+func bindValues(value reflect.Value, tag string, values map[string][]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := bindValues(value.Field(i), tag, values); err != nil {
+				return err
+			}
+			continue
+		}
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		if tag == "header" {
+			name = http.CanonicalHeaderKey(name)
+		}
+		fieldValues, ok := values[name]
+		if !ok || len(fieldValues) == 0 {
+			continue
+		}
+		if err := setField(value.Field(i), fieldValues); err != nil {
+			return fmt.Errorf("invalid %s parameter %q: %v", tag, name, err)
+		}
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setField 将字符串参数转换为字段类型，切片字段使用全部的值，其余使用第一个值
+func setField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return setField(field.Elem(), values)
+	}
+	if reflect.PtrTo(field.Type()).Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(values[0]))
+	}
+	if field.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setField(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	value := values[0]
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// validateRequired 检查标记了 binding:"required" 的字段是否为零值
+func validateRequired(value reflect.Value) error {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := validateRequired(value.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.Tag.Get("binding") == "required" && value.Field(i).IsZero() {
+			return fmt.Errorf("field %s is required", fieldName(field))
+		}
+	}
+	return nil
+}
+
+// fieldName 返回字段在请求中的名称，用于错误信息
+func fieldName(field reflect.StructField) string {
+	for _, tag := range append([]string{"json"}, bindingSources...) {
+		if name := strings.Split(field.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
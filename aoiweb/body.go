@@ -0,0 +1,150 @@
+package aoiweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const bodyBytesKey = "aoiweb/body" //上下文中缓存请求体的键
+
+// ErrBodyTooLarge 请求体超出BodyLimit设置的大小，ErrorHandler会将其渲染为413
+var ErrBodyTooLarge = errors.New("aoiweb: request body too large")
+
+// BodyBinding 将请求体解码到对象，用于ShouldBindBodyWith
+type BodyBinding interface {
+	Name() string
+	BindBody(body []byte, obj interface{}) error
+}
+
+var (
+	BindingJSON BodyBinding = jsonBinding{}
+	BindingXML  BodyBinding = xmlBinding{}
+	BindingForm BodyBinding = formBinding{} //按form标签绑定urlencoded表单
+)
+
+type jsonBinding struct{}
+
+func (jsonBinding) Name() string { return "json" }
+
+func (jsonBinding) BindBody(body []byte, obj interface{}) error {
+	if len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, obj)
+}
+
+type xmlBinding struct{}
+
+func (xmlBinding) Name() string { return "xml" }
+
+func (xmlBinding) BindBody(body []byte, obj interface{}) error {
+	if len(body) == 0 {
+		return nil
+	}
+	return xml.Unmarshal(body, obj)
+}
+
+type formBinding struct{}
+
+func (formBinding) Name() string { return "form" }
+
+func (formBinding) BindBody(body []byte, obj interface{}) error {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+	value, err := bindingTarget(obj)
+	if err != nil {
+		return err
+	}
+	return bindValues(value, "form", values)
+}
+
+// GetRawData 读取并缓存请求体，之后可以多次调用，ShouldBind等也能再次读取请求体
+func (c *Context) GetRawData() ([]byte, error) {
+	if data, ok := c.Get(bodyBytesKey); ok {
+		return data.([]byte), nil
+	}
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Set(bodyBytesKey, data)
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// ShouldBindBodyWith 使用binding将缓存的请求体绑定到obj，可以在中间件与处理函数中多次调用
+// 绑定后同样检查 binding:"required" 的字段并调用Validator
+func (c *Context) ShouldBindBodyWith(obj interface{}, binding BodyBinding) error {
+	data, err := c.GetRawData()
+	if err != nil {
+		return err
+	}
+	value, err := bindingTarget(obj)
+	if err != nil {
+		return err
+	}
+	if err := binding.BindBody(data, value.Addr().Interface()); err != nil {
+		return fmt.Errorf("invalid %s body: %w", binding.Name(), err)
+	}
+	return validate(value)
+}
+
+// BodyLimit 限制请求体最多n字节，Content-Length超出时直接返回413，
+// 读取时超出则返回ErrBodyTooLarge，通过Handle或ErrorHandler处理时响应码为413
+func BodyLimit(n int64) HandleFunc {
+	return func(c *Context) {
+		if c.Request.ContentLength > n {
+			c.Fail(http.StatusRequestEntityTooLarge, "413 Request Entity Too Large")
+			c.Abort()
+			return
+		}
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			counter := &countingReader{ReadCloser: c.Request.Body}
+			c.Request.Body = &limitedBody{
+				ReadCloser: http.MaxBytesReader(c.Writer, counter, n),
+				counter:    counter,
+				limit:      n,
+			}
+		}
+		c.Next()
+	}
+}
+
+// countingReader 记录从原始请求体读取的字节数
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// limitedBody 将http.MaxBytesReader超出限制时的错误转换为ErrBodyTooLarge
+// MaxBytesReader会多读取一个字节来判断是否超出，因此读取的字节数大于limit即表示超出
+type limitedBody struct {
+	io.ReadCloser
+	counter *countingReader
+	limit   int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.counter.n > b.limit {
+		err = ErrBodyTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,88 @@
+package aoiweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestShouldBindBodyWith(t *testing.T) {
+	r := New()
+	r.Use(func(c *Context) {
+		//模拟签名校验中间件先读取请求体
+		data, err := c.GetRawData()
+		if err != nil || !strings.Contains(string(data), "apple") {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		var req createItemRequest
+		if err := c.ShouldBindBodyWith(&req, BindingJSON); err != nil || req.Name != "apple" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Next()
+	})
+	r.Post("/shops/:shop/items", Handle(func(c *Context, req createItemRequest) (createItemResponse, error) {
+		return createItemResponse{Shop: req.Shop, Name: req.Name, Count: req.Count}, nil
+	}))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/shops/s1/items", strings.NewReader(`{"name":"apple","count":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != `{"shop":"s1","name":"apple","count":2,"tags":null,"dry":false,"trace_id":""}`+"\n" {
+		t.Fatal("body should be readable after GetRawData, got", w.Code, w.Body.String())
+	}
+
+	c := newContext(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader("name=pear&count=3")))
+	var form createItemRequest
+	if err := c.ShouldBindBodyWith(&form, BindingForm); err != nil || form.Name != "pear" || form.Count != 3 {
+		t.Fatal("form body should be bound", form, err)
+	}
+	var missing createItemRequest
+	if err := c.ShouldBindBodyWith(&missing, BindingXML); err == nil {
+		t.Fatal("invalid xml body should return error")
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	r := New()
+	r.Use(ErrorHandler(), BodyLimit(8))
+	r.Post("/raw", HandlerE(func(c *Context) error {
+		data, err := c.GetRawData()
+		if err != nil {
+			return err
+		}
+		c.String(http.StatusOK, string(data))
+		return nil
+	}))
+	r.Post("/items", Handle(func(c *Context, req createItemRequest) (createItemResponse, error) {
+		return createItemResponse{Name: req.Name}, nil
+	}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/raw", strings.NewReader("12345678")))
+	if w.Code != http.StatusOK || w.Body.String() != "12345678" {
+		t.Fatal("body within limit should be read, got", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/raw", strings.NewReader("123456789")))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatal("content length over limit should return 413, got", w.Code)
+	}
+
+	//没有Content-Length时在读取过程中超出限制
+	for _, path := range []string{"/raw", "/items"} {
+		req := httptest.NewRequest("POST", path, strings.NewReader(`{"name":"too long"}`))
+		req.ContentLength = -1
+		req.Header.Set("Content-Type", "application/json")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Fatal(path, "body over limit should return 413, got", w.Code, w.Body.String())
+		}
+	}
+
+}
@@ -0,0 +1,303 @@
+package aoiweb
+
+import (
+	"AoiFramework/aoicache"
+	"bytes"
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// errNotCacheable 响应不能缓存，等待同一个key的请求需要自行处理
+var errNotCacheable = errors.New("aoiweb: response is not cacheable")
+
+// cachedResponse 缓存的响应
+type cachedResponse struct {
+	Status int
+	Header http.Header //只包含处理函数设置的响应头
+	Body   []byte
+}
+
+// responseRecorder 记录处理函数写出的响应
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header { return r.header }
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+// CacheResponse 将GET请求的响应码、响应头与响应体缓存到group中，ttl为0时永不过期
+// keyFunc为nil时使用host与请求路径(包含query)作为key；响应带有Vary时，key还会加上Vary中各个请求头的值，
+// 内容协商或I18n返回的不同响应分别缓存，Vary: * 的响应不缓存
+// 同一个key的并发未命中只会执行一次处理函数；响应带有ETag，If-None-Match匹配时返回304
+// 请求头 Cache-Control: no-cache 时跳过缓存重新生成，no-store 时不使用缓存
+// 只缓存没有设置cookie、且没有通过Cache-Control禁止缓存的200响应
+func CacheResponse(group *aoicache.Group, ttl time.Duration, keyFunc func(*Context) string) HandleFunc {
+	if keyFunc == nil {
+		keyFunc = func(c *Context) string {
+			return c.Host() + c.Request.URL.RequestURI()
+		}
+	}
+	return func(c *Context) {
+		if c.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		directives := cacheControl(c.Request.Header.Get("Cache-Control"))
+		if directives["no-store"] {
+			c.Next()
+			return
+		}
+
+		base := keyFunc(c)
+		varyIndex := base + "\x00vary" //保存该key下的响应使用的Vary
+		vary := cachedVary(group, varyIndex)
+		key := varyKey(c, base, vary)
+		var recorded *cachedResponse //只有实际执行处理函数的请求会记录响应
+		var panicked interface{}
+		load := func(string) (value []byte, err error) {
+			//处理函数panic时先结束加载，避免等待同一个key的请求永远阻塞
+			defer func() {
+				if panicked = recover(); panicked != nil {
+					err = errNotCacheable
+				}
+			}()
+			recorded = c.recordResponse()
+			if !recorded.cacheable() {
+				return nil, errNotCacheable
+			}
+			names, ok := varyHeaders(recorded.Header)
+			if !ok {
+				return nil, errNotCacheable
+			}
+			if recorded.Header.Get("ETag") == "" {
+				recorded.Header.Set("ETag", etag(recorded.Body))
+			}
+			if value, err = recorded.encode(); err != nil || equalValues(names, vary) {
+				return value, err
+			}
+			//第一次遇到Vary或Vary发生变化时，记录新的Vary并按请求头的值另外保存
+			group.Set(varyIndex, []byte(strings.Join(names, ",")), ttl)
+			group.Set(varyKey(c, base, names), value, ttl)
+			return nil, errNotCacheable
+		}
+
+		var data aoicache.Data
+		var err error
+		if directives["no-cache"] {
+			var value []byte
+			if value, err = load(key); err == nil {
+				group.Set(key, value, ttl)
+			}
+		} else {
+			data, err = group.GetOrLoad(key, ttl, load)
+		}
+		if panicked != nil {
+			panic(panicked)
+		}
+		if recorded != nil {
+			c.writeCached(recorded)
+			return
+		}
+		var resp *cachedResponse
+		if err == nil {
+			resp, err = decodeCachedResponse(data.ByteSlice())
+		}
+		if err != nil { //等待的响应不能缓存，由当前请求自行处理
+			c.Next()
+			return
+		}
+		c.writeCached(resp)
+		c.Abort()
+	}
+}
+
+// recordResponse 执行后续处理函数，将响应记录下来而不写出
+// 之前的中间件注册的beforeWrite回调只属于当前请求，记录期间暂不执行，在重新写出响应时执行
+func (c *Context) recordResponse() *cachedResponse {
+	original, hooks := c.writer.ResponseWriter, c.writer.beforeWrite
+	before := c.Writer.Header().Clone()
+	recorder := &responseRecorder{header: c.Writer.Header().Clone()}
+	c.writer.ResponseWriter, c.writer.beforeWrite = recorder, nil
+	defer func() {
+		//恢复原始的ResponseWriter与回调，之后由writeCached重新写出
+		pending := c.writer.beforeWrite
+		if c.writer.written { //记录期间注册的回调已经执行，结果包含在记录的响应头中
+			pending = nil
+		}
+		c.writer.reset(original)
+		c.writer.beforeWrite = append(hooks, pending...)
+	}()
+	c.Next()
+
+	header := make(http.Header)
+	for key, values := range recorder.header {
+		//Vary决定缓存的key，之前的中间件设置的也需要记录
+		if key == "Vary" || !equalValues(before[key], values) {
+			header[key] = values
+		}
+	}
+	return &cachedResponse{Status: recorder.status, Header: header, Body: recorder.body.Bytes()}
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// cachedVary 返回该key下的响应使用的Vary，没有记录时为空
+func cachedVary(group *aoicache.Group, varyIndex string) []string {
+	data, err := group.GetOrLoad(varyIndex, 0, func(string) ([]byte, error) {
+		return nil, errNotCacheable
+	})
+	if err != nil || data.Len() == 0 {
+		return nil
+	}
+	return strings.Split(data.String(), ",")
+}
+
+// varyKey 在key后加上Vary中各个请求头的值
+func varyKey(c *Context, key string, vary []string) string {
+	var sb strings.Builder
+	sb.WriteString(key)
+	for _, name := range vary {
+		sb.WriteString("\x00")
+		sb.WriteString(strings.Join(c.Request.Header.Values(name), ","))
+	}
+	return sb.String()
+}
+
+// varyHeaders 返回响应Vary中排序去重后的请求头，包含 * 时返回false
+func varyHeaders(header http.Header) ([]string, bool) {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	result := names[:0]
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			result = append(result, name)
+		}
+	}
+	return result, true
+}
+
+// cacheable 只缓存没有设置cookie且允许缓存的200响应
+func (resp *cachedResponse) cacheable() bool {
+	if resp.Status != http.StatusOK || len(resp.Header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	directives := cacheControl(resp.Header.Get("Cache-Control"))
+	return !directives["no-store"] && !directives["no-cache"] && !directives["private"]
+}
+
+func (resp *cachedResponse) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(resp)
+	return buf.Bytes(), err
+}
+
+func decodeCachedResponse(data []byte) (*cachedResponse, error) {
+	resp := new(cachedResponse)
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(resp)
+	return resp, err
+}
+
+// writeCached 写出响应，ETag与If-None-Match匹配时返回304
+func (c *Context) writeCached(resp *cachedResponse) {
+	if resp.Status == 0 { //处理函数没有写出任何内容
+		return
+	}
+	header := c.Writer.Header()
+	for key, values := range resp.Header {
+		header[key] = values
+	}
+	if tag := resp.Header.Get("ETag"); tag != "" && etagMatch(c.Request.Header.Get("If-None-Match"), tag) {
+		header.Del("Content-Length")
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Status(resp.Status)
+	_, _ = c.Writer.Write(resp.Body)
+}
+
+// writeRecorded 原样写出记录的响应
+func (c *Context) writeRecorded(resp *cachedResponse) {
+	if resp.Status == 0 {
+		return
+	}
+	header := c.Writer.Header()
+	for key, values := range resp.Header {
+		header[key] = values
+	}
+	c.Status(resp.Status)
+	_, _ = c.Writer.Write(resp.Body)
+}
+
+// etag 根据响应体生成强校验的ETag
+func etag(body []byte) string {
+	sum := sha1.Sum(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// etagMatch 判断If-None-Match是否包含tag，使用弱比较
+func etagMatch(ifNoneMatch, tag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheControl 解析Cache-Control中的指令，只关心不带参数的指令
+func cacheControl(value string) map[string]bool {
+	directives := make(map[string]bool)
+	for _, directive := range strings.Split(value, ",") {
+		name := strings.ToLower(strings.TrimSpace(strings.SplitN(directive, "=", 2)[0]))
+		if name != "" {
+			directives[name] = true
+		}
+	}
+	return directives
+}
@@ -0,0 +1,164 @@
+package aoiweb
+
+import (
+	"AoiFramework/aoicache"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCacheGroup(name string) *aoicache.Group {
+	return aoicache.NewGroup(name, 1<<20, aoicache.GetterFunc(func(key string) ([]byte, error) {
+		return nil, fmt.Errorf("%s not exist", key)
+	}))
+}
+
+func TestCacheResponse(t *testing.T) {
+	var calls int32
+	r := New()
+	r.Use(CacheResponse(newCacheGroup("aoiweb-response"), time.Minute, nil))
+	r.Get("/items", func(c *Context) {
+		n := atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		c.SetHeader("X-Call", fmt.Sprint(n))
+		c.String(http.StatusOK, "items %s", c.Query("page"))
+	})
+	r.Get("/private", func(c *Context) {
+		atomic.AddInt32(&calls, 1)
+		c.SetHeader("Cache-Control", "private")
+		c.String(http.StatusOK, "private")
+	})
+
+	serve := func(target string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	//并发未命中只执行一次处理函数
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if w := serve("/items?page=1"); w.Code != http.StatusOK || w.Body.String() != "items 1" || w.Header().Get("X-Call") != "1" {
+				t.Error("unexpected response", w.Code, w.Body.String(), w.Header())
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Fatal("concurrent misses should be collapsed, got", calls)
+	}
+
+	w := serve("/items?page=1")
+	tag := w.Header().Get("ETag")
+	if w.Body.String() != "items 1" || tag == "" || calls != 1 {
+		t.Fatal("cached response should be replayed with etag", w.Body.String(), tag, calls)
+	}
+	if w := serve("/items?page=1", "If-None-Match", tag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatal("matching etag should return 304, got", w.Code)
+	}
+	if w := serve("/items?page=2"); w.Body.String() != "items 2" || calls != 2 {
+		t.Fatal("different query should miss", w.Body.String(), calls)
+	}
+	if w := serve("/items?page=1", "Cache-Control", "no-cache"); w.Header().Get("X-Call") != "3" {
+		t.Fatal("no-cache should refresh the response", w.Header().Get("X-Call"))
+	}
+	if w := serve("/items?page=1"); w.Header().Get("X-Call") != "3" {
+		t.Fatal("refreshed response should be cached", w.Header().Get("X-Call"))
+	}
+
+	serve("/private")
+	serve("/private")
+	if calls != 5 {
+		t.Fatal("private response should not be cached, got", calls)
+	}
+}
+
+type greeting struct {
+	Message string `json:"message" xml:"message"`
+}
+
+func TestCacheResponseVary(t *testing.T) {
+	var calls int32
+	bundle := NewBundle("en")
+	bundle.AddMessages("en", map[string]Message{"hello": {"other": "hello"}})
+	bundle.AddMessages("zh", map[string]Message{"hello": {"other": "你好"}})
+	r := New()
+	r.Use(I18n(bundle, I18nConfig{}), CacheResponse(newCacheGroup("aoiweb-vary"), time.Minute, nil))
+	r.Get("/hello", func(c *Context) {
+		atomic.AddInt32(&calls, 1)
+		c.Negotiate(http.StatusOK, greeting{c.T("hello")})
+	})
+	r.Get("/any", func(c *Context) {
+		atomic.AddInt32(&calls, 1)
+		c.SetHeader("Vary", "*")
+		c.String(http.StatusOK, "any")
+	})
+
+	serve := func(target, accept, language string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Accept", accept)
+		req.Header.Set("Accept-Language", language)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	tests := []struct {
+		accept, language, contentType, body string
+		calls                               int32
+	}{
+		{MIMEJSON, "zh", MIMEJSON, "你好", 1},
+		{MIMEXML, "zh", MIMEXML, "你好", 2},
+		{MIMEJSON, "en", MIMEJSON, "hello", 3},
+		{MIMEJSON, "zh", MIMEJSON, "你好", 3},
+		{MIMEXML, "zh", MIMEXML, "你好", 3},
+		{MIMEJSON, "en", MIMEJSON, "hello", 3},
+	}
+	for i, test := range tests {
+		w := serve("/hello", test.accept, test.language)
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), test.contentType) || !strings.Contains(w.Body.String(), test.body) || calls != test.calls {
+			t.Fatalf("request %d: unexpected response %q %q, calls %d", i, w.Header().Get("Content-Type"), w.Body.String(), calls)
+		}
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	serve("/any", "", "")
+	serve("/any", "", "")
+	if calls != 2 {
+		t.Fatal("response with Vary: * should not be cached, got", calls)
+	}
+}
+
+func TestCacheResponseBeforeWrite(t *testing.T) {
+	r := New()
+	r.Use(func(c *Context) {
+		c.beforeWrite(func() {
+			if id := c.Query("id"); id != "" {
+				c.SetHeader("X-Request", id)
+			}
+		})
+		c.Next()
+	}, CacheResponse(newCacheGroup("aoiweb-before-write"), time.Minute, func(c *Context) string { return c.Path }))
+	r.Get("/items", func(c *Context) {
+		c.String(http.StatusOK, "items")
+	})
+	//回调属于当前请求，在写出时执行，不能被缓存到响应中
+	for _, id := range []string{"1", "", "2"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/items?id="+id, nil))
+		if w.Body.String() != "items" || w.Header().Get("X-Request") != id {
+			t.Fatalf("request %s: beforeWrite hooks should run for each request, got %q", id, w.Header().Get("X-Request"))
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package aoiweb
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// defaultRemoteIPHeaders 受信任的代理转发客户端地址时使用的请求头，按顺序查找
+var defaultRemoteIPHeaders = []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"}
+
+// SetTrustedProxies 设置受信任的代理地址，支持单个ip与CIDR
+// 只有来自这些地址的请求，其转发头才会被ClientIP、Scheme与Host采用；默认不信任任何代理
+func (e *Engine) SetTrustedProxies(proxies []string) error {
+	cidrs := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("aoiweb: invalid trusted proxy %q", proxy)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			proxy = fmt.Sprintf("%s/%d", proxy, bits)
+		}
+		_, cidr, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("aoiweb: invalid trusted proxy %q: %v", proxy, err)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	e.trustedCIDRs = cidrs
+	return nil
+}
+
+// isTrustedProxy 判断ip是否属于受信任的代理
+func (e *Engine) isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range e.trustedCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteIP 返回直接连接的对端地址，不解析任何转发头
+func (c *Context) RemoteIP() string {
+	ip, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		return strings.TrimSpace(c.Request.RemoteAddr)
+	}
+	return ip
+}
+
+// fromTrustedProxy 请求是否由受信任的代理转发
+func (c *Context) fromTrustedProxy() bool {
+	return c.engine != nil && c.engine.isTrustedProxy(net.ParseIP(c.RemoteIP()))
+}
+
+// ClientIP 返回客户端的真实地址
+// 只有请求来自受信任的代理时才会解析 Forwarded、X-Forwarded-For 与 X-Real-IP
+func (c *Context) ClientIP() string {
+	remoteIP := c.RemoteIP()
+	if !c.fromTrustedProxy() {
+		return remoteIP
+	}
+	headers := c.engine.RemoteIPHeaders
+	if headers == nil {
+		headers = defaultRemoteIPHeaders
+	}
+	for _, name := range headers {
+		value := c.Request.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		var chain []string
+		switch http.CanonicalHeaderKey(name) {
+		case "Forwarded":
+			for _, element := range parseForwarded(c.Request.Header.Values(name)) {
+				chain = append(chain, element["for"])
+			}
+		case "X-Forwarded-For":
+			for _, value := range c.Request.Header.Values(name) {
+				chain = append(chain, strings.Split(value, ",")...)
+			}
+		default:
+			chain = []string{value}
+		}
+		if ip, ok := c.engine.clientFromChain(chain); ok {
+			return ip
+		}
+	}
+	return remoteIP
+}
+
+// clientFromChain 从右向左跳过受信任的代理，第一个不受信任的地址即为客户端
+// 链中出现非法地址时说明请求头不可信，返回false
+func (e *Engine) clientFromChain(chain []string) (string, bool) {
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := net.ParseIP(cleanNodeName(chain[i]))
+		if ip == nil {
+			return "", false
+		}
+		if i == 0 || !e.isTrustedProxy(ip) {
+			return ip.String(), true
+		}
+	}
+	return "", false
+}
+
+// cleanNodeName 去除Forwarded中节点名称的引号、端口与ipv6的方括号
+func cleanNodeName(node string) string {
+	node = strings.Trim(strings.TrimSpace(node), `"`)
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+}
+
+// parseForwarded 解析RFC 7239的Forwarded请求头，每个元素为一组键值对
+func parseForwarded(values []string) []map[string]string {
+	var elements []map[string]string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			pairs := make(map[string]string)
+			for _, pair := range strings.Split(element, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) != 2 {
+					continue
+				}
+				pairs[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+			}
+			if len(pairs) > 0 {
+				elements = append(elements, pairs)
+			}
+		}
+	}
+	return elements
+}
+
+// forwardedValue 读取受信任代理设置的转发信息，优先使用Forwarded，其次使用X-Forwarded-*
+// 多级代理时取第一个值，即最接近客户端的一侧
+func (c *Context) forwardedValue(key, header string) string {
+	if !c.fromTrustedProxy() {
+		return ""
+	}
+	if elements := parseForwarded(c.Request.Header.Values("Forwarded")); len(elements) > 0 {
+		if value := elements[0][key]; value != "" {
+			return value
+		}
+	}
+	value := c.Request.Header.Get(header)
+	if index := strings.Index(value, ","); index >= 0 {
+		value = value[:index]
+	}
+	return strings.TrimSpace(value)
+}
+
+// Scheme 返回客户端请求使用的协议，http或https
+func (c *Context) Scheme() string {
+	if proto := strings.ToLower(c.forwardedValue("proto", "X-Forwarded-Proto")); proto == "http" || proto == "https" {
+		return proto
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host 返回客户端请求的host
+func (c *Context) Host() string {
+	if host := c.forwardedValue("host", "X-Forwarded-Host"); host != "" {
+		return host
+	}
+	return c.Request.Host
+}
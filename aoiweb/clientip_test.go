@@ -0,0 +1,76 @@
+package aoiweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newClientIPContext(e *Engine, remoteAddr string, headers map[string]string) *Context {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = remoteAddr
+	req.Host = "backend:8080"
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	c := newContext(httptest.NewRecorder(), req)
+	c.engine = e
+	return c
+}
+
+func TestClientIP(t *testing.T) {
+	e := New()
+	if err := e.SetTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		expect  string
+	}{
+		{"no proxy", "1.2.3.4:5678", nil, "1.2.3.4"},
+		{"spoofed from untrusted", "1.2.3.4:5678",
+			map[string]string{"X-Forwarded-For": "8.8.8.8", "X-Real-IP": "8.8.8.8"}, "1.2.3.4"},
+		{"x-forwarded-for", "10.0.0.1:80",
+			map[string]string{"X-Forwarded-For": "8.8.8.8, 5.6.7.8, 10.0.0.2"}, "5.6.7.8"},
+		{"x-real-ip", "192.168.1.1:80",
+			map[string]string{"X-Real-IP": "5.6.7.8"}, "5.6.7.8"},
+		{"forwarded", "10.0.0.1:80",
+			map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https, for=10.0.0.3`}, "2001:db8::1"},
+		{"invalid header falls back", "10.0.0.1:80",
+			map[string]string{"X-Forwarded-For": "not-an-ip"}, "10.0.0.1"},
+		{"all trusted", "10.0.0.1:80",
+			map[string]string{"X-Forwarded-For": "10.0.0.5, 10.0.0.6"}, "10.0.0.5"},
+	}
+	for _, test := range tests {
+		c := newClientIPContext(e, test.remote, test.headers)
+		if ip := c.ClientIP(); ip != test.expect {
+			t.Errorf("%s: expect %s, got %s", test.name, test.expect, ip)
+		}
+	}
+
+	if err := e.SetTrustedProxies([]string{"10.0.0.256"}); err == nil {
+		t.Fatal("invalid proxy address should be rejected")
+	}
+}
+
+func TestSchemeAndHost(t *testing.T) {
+	e := New()
+	_ = e.SetTrustedProxies([]string{"10.0.0.1"})
+	headers := map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "example.com"}
+
+	c := newClientIPContext(e, "10.0.0.1:80", headers)
+	if c.Scheme() != "https" || c.Host() != "example.com" {
+		t.Fatal("forwarded headers from trusted proxy should be used, got", c.Scheme(), c.Host())
+	}
+	c = newClientIPContext(e, "1.2.3.4:80", headers)
+	if c.Scheme() != "http" || c.Host() != "backend:8080" {
+		t.Fatal("forwarded headers from untrusted client should be ignored, got", c.Scheme(), c.Host())
+	}
+	c = newClientIPContext(e, "10.0.0.1:80", map[string]string{"Forwarded": "proto=https;host=api.example.com"})
+	if c.Scheme() != "https" || c.Host() != "api.example.com" {
+		t.Fatal("forwarded header should be used, got", c.Scheme(), c.Host())
+	}
+}
@@ -3,7 +3,10 @@ package aoiweb
 import (
 	"encoding/json"
 	"fmt"
+	"html/template"
+	"math"
 	"net/http"
+	"sync"
 )
 
 // H 提供输出方法
@@ -20,7 +23,12 @@ type Context struct {
 	Method string
 
 	//存放请求参数信息
-	Params map[string]string
+	Params     map[string]string
+	hostParams map[string]string //host规则中的参数，重新匹配路由时保留
+	//匹配到的路由规则
+	fullPath string
+	//匹配到的处理函数，位于处理链的最后，MethodOverride修改请求方法后会被替换
+	routeHandler HandleFunc
 
 	//响应码
 	StatusCode int
@@ -30,17 +38,83 @@ type Context struct {
 	index    int
 
 	engine *Engine
+	router *router //匹配当前请求的路由，MethodOverride修改请求方法后用于重新匹配
+
+	writer responseWriter //Writer的默认实现
+
+	//请求范围内的键值对，供中间件与处理函数之间传递数据
+	mu   sync.RWMutex
+	Keys map[string]interface{}
+
+	sameSite http.SameSite //设置cookie时使用的SameSite属性
+
+	funcs template.FuncMap //请求级的模板函数，渲染时覆盖同名的占位函数
+
+	//处理过程中通过Error收集的错误
+	Errors errorMsgs
 }
 
+//abortIndex 中断后index被设置为该值，后续处理函数不再执行
+const abortIndex = math.MaxInt16
+
 //newContext 创建并返回对应的上下文
 func newContext(writer http.ResponseWriter, request *http.Request) *Context {
-	return &Context{
-		Writer:  writer,
+	c := &Context{
 		Request: request,
 		Path:    request.URL.Path,
 		Method:  request.Method,
 		index:   -1,
 	}
+	c.writer.reset(writer)
+	c.Writer = &c.writer
+	return c
+}
+
+// SetRequest 替换当前请求，同时更新Path与Method，并清除之前匹配到的参数与路由规则
+// 用于在CreateContext创建的上下文中使用自定义的请求
+func (c *Context) SetRequest(request *http.Request) {
+	c.Request = request
+	c.Path = request.URL.Path
+	c.Method = request.Method
+	c.Params = nil
+	c.hostParams = nil
+	c.fullPath = ""
+}
+
+//beforeWrite 注册在写出响应头前执行的回调
+func (c *Context) beforeWrite(fn func()) {
+	c.writer.beforeWrite = append(c.writer.beforeWrite, fn)
+}
+
+// Written 返回响应头是否已经写出
+func (c *Context) Written() bool {
+	return c.writer.written
+}
+
+// Set 在当前上下文中保存键值对
+func (c *Context) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Keys == nil {
+		c.Keys = make(map[string]interface{})
+	}
+	c.Keys[key] = value
+}
+
+// Get 获取当前上下文中保存的值
+func (c *Context) Get(key string) (value interface{}, exists bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, exists = c.Keys[key]
+	return
+}
+
+// MustGet 获取当前上下文中保存的值，不存在时panic
+func (c *Context) MustGet(key string) interface{} {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic("key \"" + key + "\" does not exist")
 }
 
 // GetFormValue 从表单各处获取键值对
@@ -73,8 +147,8 @@ func (c *Context) String(code int, format string, values ...interface{}) {
 }
 
 func (c *Context) JSON(code int, obj interface{}) {
+	c.SetHeader("Content-Type", "application/json") //响应头需要在写出响应码之前设置
 	c.Status(code)
-	c.SetHeader("Content-Type", "application/json")
 	encoder := json.NewEncoder(c.Writer)
 	err := encoder.Encode(obj)
 	if err != nil {
@@ -89,10 +163,45 @@ func (c *Context) Data(code int, data []byte) {
 func (c *Context) HTML(code int, name string, data interface{}) {
 	c.SetHeader("Content-Type", "text/html")
 	c.Status(code)
-	if err := c.engine.htmlTemplates.ExecuteTemplate(c.Writer, name, data); err != nil {
+	//html/template执行过后不能再Clone，因此每次都在副本上绑定请求级函数后执行
+	tmpl, err := c.engine.htmlTemplates.Clone()
+	if err == nil {
+		err = tmpl.Funcs(c.funcs).ExecuteTemplate(c.Writer, name, data)
+	}
+	if err != nil {
 		c.Data(500, []byte(err.Error()))
 	}
 }
+
+//setTemplateFunc 为当前请求绑定模板函数，函数名需要在templateFuncs中注册占位实现
+func (c *Context) setTemplateFunc(name string, fn interface{}) {
+	if c.funcs == nil {
+		c.funcs = make(template.FuncMap)
+	}
+	c.funcs[name] = fn
+}
+
+// Push 通过HTTP/2服务端推送发送target资源，需要在写出响应之前调用
+// 连接不支持推送(HTTP/1.x或客户端关闭了推送)时返回http.ErrNotSupported
+func (c *Context) Push(target string, opts *http.PushOptions) error {
+	pusher, ok := c.Writer.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return pusher.Push(target, opts)
+}
+
+// Redirect 重定向到指定地址
+func (c *Context) Redirect(code int, location string) {
+	c.StatusCode = code
+	http.Redirect(c.Writer, c.Request, location, code)
+}
+
+// FullPath 返回匹配到的路由规则，如 /user/:name，未匹配时为空
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
 func (c *Context) Param(key string) string {
 	s := c.Params[key]
 	return s
@@ -107,6 +216,22 @@ func (c *Context) Next() {
 	}
 }
 
+// Abort 中断处理链，当前处理函数之后的函数不再执行
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+// IsAborted 返回处理链是否被中断
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+// AbortWithStatus 写出响应码并中断处理链
+func (c *Context) AbortWithStatus(code int) {
+	c.Status(code)
+	c.Abort()
+}
+
 func (c *Context) Fail(serverError int, s string) {
 	c.Status(serverError)
 	c.Writer.Write([]byte(s))
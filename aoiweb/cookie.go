@@ -0,0 +1,39 @@
+package aoiweb
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// SetSameSite 设置之后通过SetCookie写出的cookie的SameSite属性
+func (c *Context) SetSameSite(sameSite http.SameSite) {
+	c.sameSite = sameSite
+}
+
+// SetCookie 向响应中添加Set-Cookie头，value会进行url转义
+// maxAge小于0时删除cookie，等于0时为会话cookie
+func (c *Context) SetCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) {
+	if path == "" {
+		path = "/"
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		MaxAge:   maxAge,
+		Path:     path,
+		Domain:   domain,
+		SameSite: c.sameSite,
+		Secure:   secure,
+		HttpOnly: httpOnly,
+	})
+}
+
+// Cookie 返回请求中指定名称的cookie值，并进行url反转义
+// 不存在时返回http.ErrNoCookie
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryUnescape(cookie.Value)
+}
@@ -0,0 +1,240 @@
+package aoiweb
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	csrfTokenKey    = "aoiweb/csrf-token" //上下文中保存当前token的键
+	csrfErrorKey    = "aoiweb/csrf-error" //上下文中保存校验错误的键
+	csrfTokenLength = 32
+)
+
+var (
+	ErrCSRFTokenMissing = errors.New("csrf: token missing")
+	ErrCSRFTokenInvalid = errors.New("csrf: token invalid")
+	ErrCSRFBadOrigin    = errors.New("csrf: origin not allowed")
+)
+
+// CSRFMode token的保存方式
+type CSRFMode int
+
+const (
+	// CSRFDoubleSubmit token保存在cookie中，请求时需要同时提交cookie与表单字段(或请求头)
+	CSRFDoubleSubmit CSRFMode = iota
+	// CSRFSession token保存在session中，需要先使用Sessions中间件
+	CSRFSession
+)
+
+// CSRFConfig CSRF中间件的配置
+type CSRFConfig struct {
+	Mode           CSRFMode
+	CookieName     string     //双重提交模式下保存token的cookie
+	CookiePath     string     //cookie路径
+	CookieDomain   string     //cookie域名
+	CookieMaxAge   int        //cookie有效期，单位秒
+	Secure         bool       //cookie是否只通过https发送
+	FieldName      string     //表单字段名
+	HeaderName     string     //请求头名称，供ajax请求使用
+	TrustedOrigins []string   //除当前host外允许的来源，如 "https://admin.example.com"
+	ErrorHandler   HandleFunc //校验失败时的处理函数，默认返回403
+}
+
+var DefaultCSRFConfig = CSRFConfig{
+	Mode:         CSRFDoubleSubmit,
+	CookieName:   "_csrf",
+	CookiePath:   "/",
+	CookieMaxAge: 86400,
+	FieldName:    "_csrf",
+	HeaderName:   "X-CSRF-Token",
+}
+
+func parseCSRFConfig(configs ...CSRFConfig) CSRFConfig {
+	if len(configs) == 0 {
+		return DefaultCSRFConfig
+	}
+	config := configs[0]
+	if config.CookieName == "" {
+		config.CookieName = DefaultCSRFConfig.CookieName
+	}
+	if config.CookiePath == "" {
+		config.CookiePath = DefaultCSRFConfig.CookiePath
+	}
+	if config.CookieMaxAge == 0 {
+		config.CookieMaxAge = DefaultCSRFConfig.CookieMaxAge
+	}
+	if config.FieldName == "" {
+		config.FieldName = DefaultCSRFConfig.FieldName
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = DefaultCSRFConfig.HeaderName
+	}
+	return config
+}
+
+// CSRF 提供跨站请求伪造防护，对不安全的请求方法校验来源与token
+// 模板中可以通过 {{ csrfToken }} 获取当前请求的token
+func CSRF(configs ...CSRFConfig) HandleFunc {
+	config := parseCSRFConfig(configs...)
+	errorHandler := config.ErrorHandler
+	if errorHandler == nil {
+		errorHandler = func(c *Context) {
+			c.Fail(http.StatusForbidden, "Forbidden")
+		}
+	}
+	return func(c *Context) {
+		secret := config.loadSecret(c)
+		if secret == nil {
+			secret = newCSRFSecret()
+			config.saveSecret(c, secret)
+		}
+		token := maskToken(secret)
+		c.Set(csrfTokenKey, token)
+		c.setTemplateFunc("csrfToken", func() string { return token })
+
+		if !isSafeMethod(c.Method) {
+			if err := config.validate(c, secret); err != nil {
+				c.Set(csrfErrorKey, err)
+				errorHandler(c)
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// CSRFToken 返回当前请求可以提交的token，需要先使用CSRF中间件
+func (c *Context) CSRFToken() string {
+	token, _ := c.Get(csrfTokenKey)
+	s, _ := token.(string)
+	return s
+}
+
+// CSRFError 返回CSRF校验失败的原因，供自定义的ErrorHandler使用
+func CSRFError(c *Context) error {
+	err, _ := c.Get(csrfErrorKey)
+	e, _ := err.(error)
+	return e
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// loadSecret 读取已经保存的secret，不存在时返回nil
+func (config *CSRFConfig) loadSecret(c *Context) []byte {
+	var encoded string
+	if config.Mode == CSRFSession {
+		encoded, _ = c.Session().Get(config.CookieName).(string)
+	} else {
+		encoded, _ = c.Cookie(config.CookieName)
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(secret) != csrfTokenLength {
+		return nil
+	}
+	return secret
+}
+
+func (config *CSRFConfig) saveSecret(c *Context, secret []byte) {
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	if config.Mode == CSRFSession {
+		c.Session().Set(config.CookieName, encoded)
+		return
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     config.CookieName,
+		Value:    encoded,
+		Path:     config.CookiePath,
+		Domain:   config.CookieDomain,
+		MaxAge:   config.CookieMaxAge,
+		Secure:   config.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// validate 依次校验请求来源与提交的token
+func (config *CSRFConfig) validate(c *Context, secret []byte) error {
+	if !config.checkOrigin(c) {
+		return ErrCSRFBadOrigin
+	}
+	token := c.Request.Header.Get(config.HeaderName)
+	if token == "" {
+		token = c.GetFormValue(config.FieldName)
+	}
+	if token == "" {
+		return ErrCSRFTokenMissing
+	}
+	if !validToken(token, secret) {
+		return ErrCSRFTokenInvalid
+	}
+	return nil
+}
+
+// checkOrigin 优先使用Origin，其次使用Referer，两者都不存在时交由token校验
+func (config *CSRFConfig) checkOrigin(c *Context) bool {
+	source := c.Request.Header.Get("Origin")
+	if source == "" {
+		source = c.Request.Header.Get("Referer")
+	}
+	if source == "" {
+		return true
+	}
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, c.Host()) {
+		return true
+	}
+	origin := u.Scheme + "://" + u.Host
+	for _, trusted := range config.TrustedOrigins {
+		if strings.EqualFold(origin, trusted) {
+			return true
+		}
+	}
+	return false
+}
+
+func newCSRFSecret() []byte {
+	secret := make([]byte, csrfTokenLength)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}
+
+// maskToken 每次使用随机掩码生成不同的token，避免BREACH攻击，格式为 mask + (mask xor secret)
+func maskToken(secret []byte) string {
+	mask := newCSRFSecret()
+	masked := make([]byte, 2*csrfTokenLength)
+	copy(masked, mask)
+	for i := range secret {
+		masked[csrfTokenLength+i] = mask[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+func validToken(token string, secret []byte) bool {
+	masked, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(masked) != 2*csrfTokenLength {
+		return false
+	}
+	unmasked := make([]byte, csrfTokenLength)
+	for i := range unmasked {
+		unmasked[i] = masked[i] ^ masked[csrfTokenLength+i]
+	}
+	return subtle.ConstantTimeCompare(unmasked, secret) == 1
+}
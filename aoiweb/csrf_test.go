@@ -0,0 +1,72 @@
+package aoiweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newCSRFEngine(t *testing.T) *Engine {
+	dir := t.TempDir()
+	page := `{{define "form"}}<input name="_csrf" value="{{csrfToken}}">{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "form.tmpl"), []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	r.LoadHTMLGlob(filepath.Join(dir, "*"))
+	r.Use(CSRF())
+	r.Get("/form", func(c *Context) {
+		c.HTML(http.StatusOK, "form", nil)
+	})
+	r.Post("/form", func(c *Context) {
+		c.String(http.StatusOK, "saved")
+	})
+	return r
+}
+
+func TestCSRF(t *testing.T) {
+	r := newCSRFEngine(t)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/form", nil)
+	r.ServeHTTP(w, req)
+	cookies := w.Result().Cookies()
+	body := w.Body.String()
+	start := strings.Index(body, `value="`) + len(`value="`)
+	token := body[start : start+strings.Index(body[start:], `"`)]
+	if len(cookies) != 1 || token == "" {
+		t.Fatal("form should render csrf token, got", body)
+	}
+
+	post := func(token, origin string) *httptest.ResponseRecorder {
+		form := url.Values{"_csrf": {token}}
+		req, _ := http.NewRequest("POST", "/form", strings.NewReader(form.Encode()))
+		req.Host = "example.com"
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		req.AddCookie(cookies[0])
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post(token, "https://example.com"); w.Code != http.StatusOK || w.Body.String() != "saved" {
+		t.Fatal("valid token should pass, got", w.Code)
+	}
+	if w := post("", ""); w.Code != http.StatusForbidden {
+		t.Fatal("missing token should be rejected, got", w.Code)
+	}
+	tampered := []byte(token)
+	tampered[0] ^= 1 //修改掩码的第一个字节
+	if w := post(string(tampered), ""); w.Code != http.StatusForbidden {
+		t.Fatal("invalid token should be rejected, got", w.Code)
+	}
+	if w := post(token, "https://evil.com"); w.Code != http.StatusForbidden || w.Body.String() == "saved" {
+		t.Fatal("cross origin request should be rejected, got", w.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package aoiweb
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"text/tabwriter"
+)
+
+// RegisterDebug 在分组下挂载调试页面：
+//
+//	/pprof/      pprof首页与各项profile
+//	/vars        expvar变量
+//	/routes      已注册的路由表
+//	/goroutines  所有goroutine的调用栈
+//
+// 调试页面不做任何保护，需要通过分组的中间件自行添加鉴权，例如只在预发环境开启
+func RegisterDebug(group *RouterGroup) {
+	//静态路由需要先于通配路由注册
+	group.Get("/pprof/", WrapF(pprof.Index))
+	group.Get("/pprof/cmdline", WrapF(pprof.Cmdline))
+	group.Get("/pprof/profile", WrapF(pprof.Profile))
+	group.Get("/pprof/symbol", WrapF(pprof.Symbol))
+	group.Post("/pprof/symbol", WrapF(pprof.Symbol))
+	group.Get("/pprof/trace", WrapF(pprof.Trace))
+	//pprof.Index依赖固定的 /debug/pprof/ 前缀，因此按名称直接查找profile
+	group.Get("/pprof/:name", func(c *Context) { pprof.Handler(c.Param("name")).ServeHTTP(c.Writer, c.Request) })
+
+	group.Get("/vars", WrapH(expvar.Handler()))
+	group.Get("/routes", debugRoutes)
+	group.Get("/goroutines", debugGoroutines)
+}
+
+// debugRoutes 以表格形式输出所有路由
+func debugRoutes(c *Context) {
+	c.SetHeader("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	w := tabwriter.NewWriter(c.Writer, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH")
+	for _, route := range c.engine.Routes() {
+		fmt.Fprintf(w, "%s\t%s\n", route.Method, route.Host+route.Path)
+	}
+	w.Flush()
+}
+
+// debugGoroutines 输出所有goroutine的完整调用栈
+func debugGoroutines(c *Context) {
+	c.SetHeader("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "goroutines: %d\n\n", runtime.NumGoroutine())
+	_ = runtimepprof.Lookup("goroutine").WriteTo(c.Writer, 2)
+}
@@ -0,0 +1,42 @@
+package aoiweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegisterDebug(t *testing.T) {
+	r := New()
+	debug := r.Group("/debug")
+	debug.Use(func(c *Context) {
+		if c.Query("token") != "secret" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Next()
+	})
+	RegisterDebug(debug)
+
+	tests := []struct {
+		path     string
+		code     int
+		contains string
+	}{
+		{"/debug/routes", http.StatusUnauthorized, ""},
+		{"/debug/routes?token=secret", http.StatusOK, "/debug/pprof/:name"},
+		{"/debug/pprof/?token=secret", http.StatusOK, "goroutine"},
+		{"/debug/pprof/heap?token=secret&debug=1", http.StatusOK, "heap profile"},
+		{"/debug/vars?token=secret", http.StatusOK, "memstats"},
+		{"/debug/goroutines?token=secret", http.StatusOK, "goroutine"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		r.ServeHTTP(w, req)
+		if w.Code != test.code || !strings.Contains(w.Body.String(), test.contains) {
+			t.Errorf("%s: expect %d containing %q, got %d", test.path, test.code, test.contains, w.Code)
+		}
+	}
+}
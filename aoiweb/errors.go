@@ -0,0 +1,194 @@
+package aoiweb
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ErrorType 错误的类型，决定错误信息是否可以返回给客户端
+type ErrorType uint
+
+const (
+	ErrorTypePrivate ErrorType = 1 << iota //内部错误，只记录日志，不返回给客户端
+	ErrorTypePublic                        //可以返回给客户端的错误
+	ErrorTypeBind                          //请求参数绑定失败，默认响应码为400
+
+	ErrorTypeAny ErrorType = 1<<iota - 1
+)
+
+// Error 处理过程中收集的错误
+type Error struct {
+	Err    error
+	Type   ErrorType
+	Status int //响应码，为0时由ErrorHandler根据错误类型决定
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// SetType 设置错误类型
+func (e *Error) SetType(typ ErrorType) *Error {
+	e.Type = typ
+	return e
+}
+
+// SetStatus 设置渲染错误时使用的响应码
+func (e *Error) SetStatus(code int) *Error {
+	e.Status = code
+	return e
+}
+
+// IsType 判断错误是否属于指定类型
+func (e *Error) IsType(typ ErrorType) bool {
+	return e.Type&typ > 0
+}
+
+// errorMsgs 上下文中收集的错误列表
+type errorMsgs []*Error
+
+// ByType 返回指定类型的错误
+func (a errorMsgs) ByType(typ ErrorType) errorMsgs {
+	var result errorMsgs
+	for _, err := range a {
+		if err.IsType(typ) {
+			result = append(result, err)
+		}
+	}
+	return result
+}
+
+// Last 返回最后一个错误，没有错误时返回nil
+func (a errorMsgs) Last() *Error {
+	if len(a) == 0 {
+		return nil
+	}
+	return a[len(a)-1]
+}
+
+func (a errorMsgs) String() string {
+	messages := make([]string, len(a))
+	for i, err := range a {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Error 将错误添加到上下文中，默认为ErrorTypePrivate，返回值可以继续设置类型与响应码
+// err包装了*Error时保留外层的错误信息，只继承内层的类型与响应码
+func (c *Context) Error(err error) *Error {
+	if err == nil {
+		panic("aoiweb: err is nil")
+	}
+	parsed, ok := err.(*Error)
+	if !ok {
+		parsed = &Error{Err: err, Type: ErrorTypePrivate}
+		var inner *Error
+		if errors.As(err, &inner) {
+			parsed.Type, parsed.Status = inner.Type, inner.Status
+		}
+	}
+	c.Errors = append(c.Errors, parsed)
+	return parsed
+}
+
+// AbortWithError 记录错误与响应码并中断处理链，响应由ErrorHandler统一写出
+func (c *Context) AbortWithError(code int, err error) *Error {
+	c.Abort()
+	return c.Error(err).SetStatus(code)
+}
+
+// HandlerE 将返回错误的处理函数转换为HandleFunc，返回的错误会被记录并中断处理链
+func HandlerE(handler func(*Context) error) HandleFunc {
+	return func(c *Context) {
+		if err := handler(c); err != nil {
+			c.Error(err)
+			c.Abort()
+		}
+	}
+}
+
+// Problem RFC 7807 定义的错误响应
+type Problem struct {
+	XMLName  xml.Name `json:"-" xml:"urn:ietf:rfc:7807 problem"`
+	Type     string   `json:"type" xml:"type"`
+	Title    string   `json:"title" xml:"title"`
+	Status   int      `json:"status" xml:"status"`
+	Detail   string   `json:"detail,omitempty" xml:"detail,omitempty"`
+	Instance string   `json:"instance,omitempty" xml:"instance,omitempty"`
+	Errors   []string `json:"errors,omitempty" xml:"errors>i,omitempty"` //多个公开错误时的全部信息
+}
+
+// ErrorHandler 在处理链结束后将收集到的错误以RFC 7807 problem details格式写出
+// 私有错误只记录日志，响应中不包含其内容；处理函数已经写出响应时不再处理
+func ErrorHandler() HandleFunc {
+	return func(c *Context) {
+		c.Next()
+		if len(c.Errors) == 0 {
+			return
+		}
+		if private := c.Errors.ByType(ErrorTypePrivate); len(private) > 0 {
+			log.Printf("[error] %s %s: %s", c.Method, c.Path, private.String())
+		}
+		if c.Written() {
+			return
+		}
+		c.renderProblem()
+	}
+}
+
+// renderProblem 将收集到的错误写出为problem details，客户端只接受xml时使用 application/problem+xml
+func (c *Context) renderProblem() {
+	status := errorStatus(c)
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Instance: c.Request.URL.Path,
+	}
+	for _, err := range c.Errors.ByType(ErrorTypePublic | ErrorTypeBind) {
+		problem.Errors = append(problem.Errors, err.Error())
+	}
+	switch len(problem.Errors) {
+	case 0:
+	case 1:
+		problem.Detail = problem.Errors[0]
+		problem.Errors = nil
+	default:
+		problem.Detail = problem.Errors[len(problem.Errors)-1]
+	}
+
+	if format := c.NegotiateFormat(MIMEJSON, MIMEXML, MIMEXML2); format == MIMEXML || format == MIMEXML2 {
+		c.renderXML(status, "application/problem+xml", problem)
+		return
+	}
+	c.SetHeader("Content-Type", "application/problem+json")
+	c.Status(status)
+	_ = json.NewEncoder(c.Writer).Encode(problem)
+}
+
+// errorStatus 优先使用最后一个显式设置的响应码，请求体超出限制为413，参数绑定错误为400，其余为500
+func errorStatus(c *Context) int {
+	for i := len(c.Errors) - 1; i >= 0; i-- {
+		if c.Errors[i].Status != 0 {
+			return c.Errors[i].Status
+		}
+	}
+	for _, err := range c.Errors {
+		if errors.Is(err, ErrBodyTooLarge) {
+			return http.StatusRequestEntityTooLarge
+		}
+	}
+	if len(c.Errors.ByType(ErrorTypeBind)) > 0 {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
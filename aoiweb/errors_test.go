@@ -0,0 +1,80 @@
+package aoiweb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorHandler(t *testing.T) {
+	r := New()
+	r.Use(ErrorHandler())
+	r.Get("/private", HandlerE(func(c *Context) error {
+		return errors.New("database password is wrong")
+	}))
+	r.Get("/public", func(c *Context) {
+		c.Error(errors.New("name is required")).SetType(ErrorTypeBind)
+		c.Error(errors.New("age is required")).SetType(ErrorTypeBind)
+	})
+	r.Get("/abort", func(c *Context) {
+		c.AbortWithError(http.StatusNotFound, errors.New("user not found")).SetType(ErrorTypePublic)
+	})
+	r.Get("/written", func(c *Context) {
+		c.Error(errors.New("ignored"))
+		c.String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		detail string
+		errors int
+	}{
+		{"/private", http.StatusInternalServerError, "", 0},
+		{"/public", http.StatusBadRequest, "age is required", 2},
+		{"/abort", http.StatusNotFound, "user not found", 0},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		var problem Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatal(test.path, err)
+		}
+		if w.Code != test.status || problem.Status != test.status || w.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s: unexpected response %d %s", test.path, w.Code, w.Body.String())
+		}
+		if problem.Detail != test.detail || len(problem.Errors) != test.errors || problem.Instance != test.path {
+			t.Errorf("%s: unexpected problem %+v", test.path, problem)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/written", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Fatal("written response should not be replaced, got", w.Code, w.Body.String())
+	}
+}
+
+func TestContextError(t *testing.T) {
+	c := newContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	wrapped := &Error{Err: errors.New("bad input"), Type: ErrorTypeBind}
+	c.Error(errors.New("internal"))
+	if c.Error(wrapped) != wrapped {
+		t.Fatal("*Error should be collected as is")
+	}
+	if len(c.Errors) != 2 || len(c.Errors.ByType(ErrorTypeBind)) != 1 || c.Errors.Last() != wrapped {
+		t.Fatal("unexpected errors", c.Errors)
+	}
+	if c.Errors.String() != "internal; bad input" || !errors.Is(wrapped, wrapped.Err) {
+		t.Fatal("unexpected error message", c.Errors.String())
+	}
+	outer := fmt.Errorf("create user: %w", &Error{Err: errors.New("conflict"), Type: ErrorTypePublic, Status: http.StatusConflict})
+	collected := c.Error(outer)
+	if collected.Error() != "create user: conflict" || collected.Type != ErrorTypePublic || collected.Status != http.StatusConflict {
+		t.Fatal("wrapped *Error should keep the outer message and inherit type and status", collected)
+	}
+}
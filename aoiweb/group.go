@@ -11,22 +11,64 @@ type RouterGroup struct {
 	middlewares []HandleFunc //提供中间件功能
 	parent      *RouterGroup //支持嵌套分组
 	engine      *Engine      //使用engine结构体的各个方法
+	router      *router      //路由注册到的路由树，Host创建的分组使用独立的路由
+	host        string       //所属的host，默认路由为空
 }
 
 //addRoute 向Engine Map中添加新的规则
-func (group *RouterGroup) addRoute(method, pre string, handler HandleFunc) {
+func (group *RouterGroup) addRoute(method, pre string, handler HandleFunc) *Route {
 	pattern := group.prefix + pre
-	group.engine.router.addRoute(method, pattern, handler)
+	return group.router.addRoute(method, pattern, handler)
 }
 
 // Get 添加Get方法路径，还需要判断路径合法性，暂时没有实现
-func (group *RouterGroup) Get(pattern string, handler HandleFunc) {
-	group.addRoute("GET", pattern, handler)
+func (group *RouterGroup) Get(pattern string, handler HandleFunc) *Route {
+	return group.addRoute("GET", pattern, handler)
 }
 
 // Post 添加 Post方法路径，还需要判断路径合法性，暂时没有实现
-func (group *RouterGroup) Post(pattern string, handler HandleFunc) {
-	group.addRoute("POST", pattern, handler)
+func (group *RouterGroup) Post(pattern string, handler HandleFunc) *Route {
+	return group.addRoute("POST", pattern, handler)
+}
+
+// Handle 添加指定请求方法的路径，返回的Route可以继续补充文档信息
+func (group *RouterGroup) Handle(method, pattern string, handler HandleFunc) *Route {
+	return group.addRoute(method, pattern, handler)
+}
+
+// Put 添加 Put方法路径
+func (group *RouterGroup) Put(pattern string, handler HandleFunc) *Route {
+	return group.addRoute("PUT", pattern, handler)
+}
+
+// Delete 添加 Delete方法路径
+func (group *RouterGroup) Delete(pattern string, handler HandleFunc) *Route {
+	return group.addRoute("DELETE", pattern, handler)
+}
+
+// Patch 添加 Patch方法路径
+func (group *RouterGroup) Patch(pattern string, handler HandleFunc) *Route {
+	return group.addRoute("PATCH", pattern, handler)
+}
+
+// Head 添加 Head方法路径
+func (group *RouterGroup) Head(pattern string, handler HandleFunc) *Route {
+	return group.addRoute("HEAD", pattern, handler)
+}
+
+// Options 添加 Options方法路径
+func (group *RouterGroup) Options(pattern string, handler HandleFunc) *Route {
+	return group.addRoute("OPTIONS", pattern, handler)
+}
+
+// anyMethods Any注册的请求方法
+var anyMethods = []string{"GET", "POST", "PUT", "PATCH", "HEAD", "OPTIONS", "DELETE", "CONNECT", "TRACE"}
+
+// Any 为所有常用请求方法添加路径
+func (group *RouterGroup) Any(pattern string, handler HandleFunc) {
+	for _, method := range anyMethods {
+		group.addRoute(method, pattern, handler)
+	}
 }
 
 // Group 传入前缀返回一个分组,当前分组前缀由创建它的分组前缀与当前传入参数拼接取得
@@ -36,6 +78,8 @@ func (group *RouterGroup) Group(prefix string) *RouterGroup {
 		prefix: group.prefix + prefix,
 		parent: group,
 		engine: engine,
+		router: group.router,
+		host:   group.host,
 	}
 	engine.groups = append(engine.groups, g)
 	return g
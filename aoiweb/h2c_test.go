@@ -0,0 +1,56 @@
+package aoiweb
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/http2"
+)
+
+func TestH2C(t *testing.T) {
+	r := New()
+	r.UseH2C = true
+	var pushErr error
+	r.Get("/", func(c *Context) {
+		pushErr = c.Push("/static/app.css", nil)
+		c.String(http.StatusOK, "proto %d", c.Request.ProtoMajor)
+	})
+	server := httptest.NewServer(r.Handler())
+	defer server.Close()
+
+	//使用明文连接发送HTTP/2请求(prior knowledge)
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 || string(body) != "proto 2" {
+		t.Fatalf("expect HTTP/2 response, got %s %q", resp.Proto, body)
+	}
+	//Go客户端关闭了服务端推送
+	if pushErr != http.ErrNotSupported {
+		t.Fatal("push should report not supported, got", pushErr)
+	}
+
+	//普通的HTTP/1.1请求仍然可以处理
+	resp, err = http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.ProtoMajor != 1 || string(body) != "proto 1" {
+		t.Fatalf("expect HTTP/1.1 response, got %s %q", resp.Proto, body)
+	}
+}
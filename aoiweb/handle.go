@@ -0,0 +1,30 @@
+package aoiweb
+
+import "net/http"
+
+// Handle 将类型化的处理函数转换为HandleFunc
+// 请求通过ShouldBind绑定到Req，失败时返回400；handler返回的错误记录到上下文并写出problem details
+// 成功时根据Accept请求头将Resp以json或xml格式返回，handler已经写出响应时不再处理
+func Handle[Req, Resp any](handler func(c *Context, req Req) (Resp, error)) HandleFunc {
+	return func(c *Context) {
+		var req Req
+		if err := c.ShouldBind(&req); err != nil {
+			c.Error(err).SetType(ErrorTypeBind)
+			c.Abort()
+			c.renderProblem()
+			return
+		}
+		resp, err := handler(c, req)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			if !c.Written() {
+				c.renderProblem()
+			}
+			return
+		}
+		if !c.Written() {
+			c.Negotiate(http.StatusOK, resp)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package aoiweb
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type createItemRequest struct {
+	Shop    string   `path:"shop"`
+	Name    string   `json:"name" form:"name" binding:"required"`
+	Count   int      `json:"count" form:"count"`
+	Tags    []string `query:"tag"`
+	Dry     bool     `query:"dry"`
+	TraceID string   `header:"X-Trace-Id"`
+}
+
+func (r *createItemRequest) Validate() error {
+	if r.Count < 0 {
+		return errors.New("count must not be negative")
+	}
+	return nil
+}
+
+type createItemResponse struct {
+	Shop    string   `json:"shop" xml:"shop"`
+	Name    string   `json:"name" xml:"name"`
+	Count   int      `json:"count" xml:"count"`
+	Tags    []string `json:"tags" xml:"tag"`
+	Dry     bool     `json:"dry" xml:"dry"`
+	TraceID string   `json:"trace_id" xml:"trace_id"`
+}
+
+func TestHandle(t *testing.T) {
+	r := New()
+	r.Post("/shops/:shop/items", Handle(func(c *Context, req createItemRequest) (createItemResponse, error) {
+		if req.Name == "forbidden" {
+			return createItemResponse{}, &Error{Err: errors.New("name is forbidden"), Type: ErrorTypePublic, Status: http.StatusForbidden}
+		}
+		return createItemResponse{req.Shop, req.Name, req.Count, req.Tags, req.Dry, req.TraceID}, nil
+	}))
+
+	serve := func(contentType, body, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/shops/s1/items?tag=a&tag=b&dry=true", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-Trace-Id", "t1")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("application/json", `{"name":"apple","count":3}`, "")
+	var resp createItemResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Shop != "s1" || resp.Name != "apple" || resp.Count != 3 ||
+		len(resp.Tags) != 2 || !resp.Dry || resp.TraceID != "t1" {
+		t.Fatal("unexpected json response", w.Code, w.Body.String())
+	}
+
+	w = serve("application/x-www-form-urlencoded", "name=pear&count=2", "text/html, application/xml;q=0.9, application/json;q=0.8")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != MIMEXML ||
+		!strings.Contains(w.Body.String(), "<name>pear</name><count>2</count>") {
+		t.Fatal("unexpected xml response", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		contentType string
+		body        string
+		status      int
+		detail      string
+	}{
+		{"application/json", `{"count":1}`, http.StatusBadRequest, "field name is required"},
+		{"application/json", `{"name":"x","count":-1}`, http.StatusBadRequest, "count must not be negative"},
+		{"application/x-www-form-urlencoded", "name=x&count=abc", http.StatusBadRequest, `invalid form parameter "count"`},
+		{"application/json", `{"name":`, http.StatusBadRequest, "invalid json body"},
+		{"application/json", `{"name":"forbidden"}`, http.StatusForbidden, "name is forbidden"},
+	}
+	for _, test := range tests {
+		w := serve(test.contentType, test.body, "")
+		var problem Problem
+		_ = json.Unmarshal(w.Body.Bytes(), &problem)
+		if w.Code != test.status || w.Header().Get("Content-Type") != "application/problem+json" ||
+			!strings.Contains(problem.Detail, test.detail) {
+			t.Errorf("%s: unexpected response %d %s", test.body, w.Code, w.Body.String())
+		}
+	}
+
+	if w := serve("application/json", `{"name":"a"}`, "text/html"); w.Code != http.StatusNotAcceptable {
+		t.Fatal("unacceptable format should return 406, got", w.Code)
+	}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := map[string]string{
+		"":                                 MIMEJSON,
+		"application/xml":                  MIMEXML,
+		"text/*;q=0.5, application/json":   MIMEJSON,
+		"application/json;q=0.1, text/xml": MIMEXML2,
+		"*/*":                              MIMEJSON,
+		"text/html, application/json;q=0":  "",
+	}
+	for accept, expect := range tests {
+		c := newContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		c.Request.Header.Set("Accept", accept)
+		if got := c.NegotiateFormat(MIMEJSON, MIMEXML, MIMEXML2); got != expect {
+			t.Errorf("%q: expect %q, got %q", accept, expect, got)
+		}
+	}
+}
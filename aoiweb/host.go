@@ -0,0 +1,83 @@
+package aoiweb
+
+import (
+	"net"
+	"strings"
+)
+
+// hostRouter 按host匹配的独立路由
+type hostRouter struct {
+	pattern string
+	parts   []string //按 . 分隔的host各部分，以 : 开头的部分为参数
+	router  *router
+	group   *RouterGroup
+}
+
+// Host 返回只处理指定host请求的分组，该分组拥有独立的路由树
+// 支持参数，如 :tenant.example.com，参数可以通过Context.Param获取
+// 没有匹配到任何host的请求由默认路由处理；Engine上注册的中间件对所有host生效
+func (e *Engine) Host(pattern string) *RouterGroup {
+	pattern = strings.ToLower(pattern)
+	for _, host := range e.hosts {
+		if host.pattern == pattern {
+			return host.group
+		}
+	}
+	host := &hostRouter{
+		pattern: pattern,
+		parts:   strings.Split(pattern, "."),
+		router:  newRouter(),
+	}
+	host.group = &RouterGroup{
+		engine: e,
+		router: host.router,
+		host:   pattern,
+	}
+	e.hosts = append(e.hosts, host)
+	e.groups = append(e.groups, host.group)
+	return host.group
+}
+
+// matchHost 查找请求host对应的路由，优先精确匹配，其次按注册顺序匹配带参数的host
+func (e *Engine) matchHost(requestHost string) (*hostRouter, map[string]string) {
+	if len(e.hosts) == 0 {
+		return nil, nil
+	}
+	if host, _, err := net.SplitHostPort(requestHost); err == nil {
+		requestHost = host
+	}
+	requestHost = strings.ToLower(strings.TrimSuffix(requestHost, "."))
+	for _, host := range e.hosts {
+		if host.pattern == requestHost {
+			return host, nil
+		}
+	}
+	parts := strings.Split(requestHost, ".")
+	for _, host := range e.hosts {
+		if params, ok := host.match(parts); ok {
+			return host, params
+		}
+	}
+	return nil, nil
+}
+
+// match 逐段匹配host，参数部分可以匹配任意非空值
+func (h *hostRouter) match(parts []string) (map[string]string, bool) {
+	if len(parts) != len(h.parts) {
+		return nil, false
+	}
+	var params map[string]string
+	for i, part := range h.parts {
+		if strings.HasPrefix(part, ":") && parts[i] != "" {
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[part[1:]] = parts[i]
+			continue
+		}
+		if part != parts[i] {
+			return nil, false
+		}
+	}
+	return params, params != nil
+}
@@ -0,0 +1,65 @@
+package aoiweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHost(t *testing.T) {
+	r := New()
+	r.Use(func(c *Context) {
+		c.SetHeader("X-Global", "1")
+		c.Next()
+	})
+	r.Get("/", func(c *Context) {
+		c.String(http.StatusOK, "default")
+	})
+	api := r.Host("api.example.com")
+	api.Get("/", func(c *Context) {
+		c.String(http.StatusOK, "api")
+	})
+	tenant := r.Host(":tenant.example.com")
+	tenant.Use(func(c *Context) {
+		c.SetHeader("X-Tenant", c.Param("tenant"))
+		c.Next()
+	})
+	tenant.Group("/users").Get("/:id", func(c *Context) {
+		c.String(http.StatusOK, "%s/%s %s", c.Param("tenant"), c.Param("id"), c.FullPath())
+	})
+	if r.Host("API.example.com") != api {
+		t.Fatal("the same host should return the same group")
+	}
+
+	tests := []struct {
+		host   string
+		path   string
+		status int
+		body   string
+		tenant string
+	}{
+		{"api.example.com", "/", http.StatusOK, "api", ""},
+		{"API.Example.com:8080", "/", http.StatusOK, "api", ""},
+		{"acme.example.com", "/users/7", http.StatusOK, "acme/7 /users/:id", "acme"},
+		{"acme.example.com", "/", http.StatusNotFound, "404 NOT FOUND / \n", "acme"},
+		{"localhost", "/", http.StatusOK, "default", ""},
+		{"a.b.example.com", "/users/7", http.StatusNotFound, "404 NOT FOUND /users/7 \n", ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", test.path, nil)
+		req.Host = test.host
+		r.ServeHTTP(w, req)
+		if w.Code != test.status || w.Body.String() != test.body {
+			t.Errorf("%s%s: unexpected response %d %q", test.host, test.path, w.Code, w.Body.String())
+		}
+		if w.Header().Get("X-Tenant") != test.tenant || w.Header().Get("X-Global") != "1" {
+			t.Errorf("%s%s: unexpected middlewares %v", test.host, test.path, w.Header())
+		}
+	}
+
+	routes := r.Routes()
+	if len(routes) != 3 || routes[1].Host != "api.example.com" || routes[2].Path != "/users/:id" {
+		t.Fatal("unexpected routes", routes)
+	}
+}
@@ -0,0 +1,378 @@
+package aoiweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const localizerKey = "aoiweb/localizer" //上下文中保存当前请求语言的键
+
+// Message 一条消息的各个复数形式，键为 zero、one、two、few、many、other
+// 没有复数变化的消息只有other
+type Message map[string]string
+
+// UnmarshalJSON 消息可以是字符串，也可以是各复数形式组成的对象
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*m = Message{"other": text}
+		return nil
+	}
+	var forms map[string]string
+	if err := json.Unmarshal(data, &forms); err != nil {
+		return err
+	}
+	*m = forms
+	return nil
+}
+
+// PluralRule 根据数量返回使用的复数形式
+type PluralRule func(n float64) string
+
+// pluralRules 内置的复数规则，按语言(不含地区)查找，未列出的语言使用英语规则
+var pluralRules = map[string]PluralRule{
+	"en": pluralOneOther,
+	"de": pluralOneOther,
+	"es": pluralOneOther,
+	"it": pluralOneOther,
+	"fr": func(n float64) string {
+		if n >= 0 && n < 2 {
+			return "one"
+		}
+		return "other"
+	},
+	"zh": pluralOther,
+	"ja": pluralOther,
+	"ko": pluralOther,
+	"ru": pluralSlavic,
+	"uk": pluralSlavic,
+}
+
+func pluralOther(float64) string { return "other" }
+
+func pluralOneOther(n float64) string {
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+// pluralSlavic 俄语等斯拉夫语言的整数复数规则
+func pluralSlavic(n float64) string {
+	if n != float64(int64(n)) {
+		return "other"
+	}
+	i := int64(n)
+	switch {
+	case i%10 == 1 && i%100 != 11:
+		return "one"
+	case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+		return "few"
+	}
+	return "many"
+}
+
+// Bundle 保存所有语言的消息
+type Bundle struct {
+	DefaultLocale string //没有匹配到任何语言时使用，同时作为缺失消息的兜底
+
+	mu       sync.RWMutex
+	messages map[string]map[string]Message //语言 -> 键 -> 消息，语言统一为小写
+	locales  map[string]string             //小写语言 -> 原始写法
+	rules    map[string]PluralRule
+}
+
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		DefaultLocale: defaultLocale,
+		messages:      make(map[string]map[string]Message),
+		locales:       make(map[string]string),
+		rules:         make(map[string]PluralRule),
+	}
+}
+
+// AddMessages 添加语言的消息，已存在的键会被覆盖
+func (b *Bundle) AddMessages(locale string, messages map[string]Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := strings.ToLower(locale)
+	if b.messages[key] == nil {
+		b.messages[key] = make(map[string]Message)
+		b.locales[key] = locale
+	}
+	for id, message := range messages {
+		b.messages[key][id] = message
+	}
+}
+
+// LoadMessages 解析json格式的消息
+func (b *Bundle) LoadMessages(locale string, data []byte) error {
+	var messages map[string]Message
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("aoiweb: parse messages of %s: %v", locale, err)
+	}
+	b.AddMessages(locale, messages)
+	return nil
+}
+
+// LoadMessageFiles 加载匹配pattern的json消息文件，文件名即语言，如 locales/zh-CN.json
+func (b *Bundle) LoadMessageFiles(pattern string) error {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		locale := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if err := b.LoadMessages(locale, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetPluralRule 设置语言的复数规则，lang不含地区，如 pl
+func (b *Bundle) SetPluralRule(lang string, rule PluralRule) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rules[strings.ToLower(lang)] = rule
+}
+
+// Locales 返回所有已加载的语言
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	locales := make([]string, 0, len(b.locales))
+	for _, locale := range b.locales {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// fallbackChain 返回语言的回退链，如 zh-Hant-TW -> zh-Hant -> zh
+func fallbackChain(locale string) []string {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	var chain []string
+	for locale != "" {
+		chain = append(chain, locale)
+		index := strings.LastIndex(locale, "-")
+		if index < 0 {
+			break
+		}
+		locale = locale[:index]
+	}
+	return chain
+}
+
+// Match 按顺序为候选语言查找已加载的语言，每个候选语言都会沿回退链查找，都不匹配时返回DefaultLocale
+func (b *Bundle) Match(candidates ...string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, candidate := range candidates {
+		for _, locale := range fallbackChain(candidate) {
+			if original, ok := b.locales[locale]; ok {
+				return original
+			}
+		}
+	}
+	return b.DefaultLocale
+}
+
+// Translate 使用locale翻译key，args为参数，格式见Context.T
+// 消息沿回退链查找，最后查找DefaultLocale，都不存在时返回key
+func (b *Bundle) Translate(locale, key string, args ...interface{}) string {
+	params := translationParams(args)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, candidate := range append(fallbackChain(locale), fallbackChain(b.DefaultLocale)...) {
+		message, ok := b.messages[candidate][key]
+		if !ok {
+			continue
+		}
+		text := message["other"]
+		if count, ok := pluralCount(params); ok {
+			text = b.pluralForm(candidate, message, count)
+		}
+		return interpolate(text, params)
+	}
+	return key
+}
+
+// pluralForm 选择复数形式，数量为0且定义了zero时优先使用zero
+func (b *Bundle) pluralForm(locale string, message Message, count float64) string {
+	if text, ok := message["zero"]; ok && count == 0 {
+		return text
+	}
+	chain := fallbackChain(locale)
+	lang := chain[len(chain)-1]
+	rule, ok := b.rules[lang]
+	if !ok {
+		if rule, ok = pluralRules[lang]; !ok {
+			rule = pluralOneOther
+		}
+	}
+	if text, ok := message[rule(count)]; ok {
+		return text
+	}
+	return message["other"]
+}
+
+// translationParams 参数可以是一个map，也可以是交替出现的名称与值，便于在模板中使用
+func translationParams(args []interface{}) map[string]interface{} {
+	if len(args) == 1 {
+		switch params := args[0].(type) {
+		case H:
+			return params
+		case map[string]interface{}:
+			return params
+		}
+	}
+	params := make(map[string]interface{}, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		params[fmt.Sprint(args[i])] = args[i+1]
+	}
+	return params
+}
+
+// pluralCount 读取参数count作为复数的数量
+func pluralCount(params map[string]interface{}) (float64, bool) {
+	value, ok := params["count"]
+	if !ok {
+		return 0, false
+	}
+	switch n := value.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	f, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+	return f, err == nil
+}
+
+// interpolate 将消息中的 {name} 替换为参数值
+func interpolate(text string, params map[string]interface{}) string {
+	if len(params) == 0 || !strings.Contains(text, "{") {
+		return text
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// I18nConfig 语言解析的配置
+type I18nConfig struct {
+	QueryKey   string //从query参数中读取语言，为空时不使用
+	CookieName string //从cookie中读取语言，为空时不使用
+}
+
+// DefaultI18nConfig 默认从 ?lang= 与名为lang的cookie中读取语言
+var DefaultI18nConfig = I18nConfig{
+	QueryKey:   "lang",
+	CookieName: "lang",
+}
+
+// I18n 为每个请求解析语言，依次使用query参数、cookie与Accept-Language，
+// 之后可以通过c.T翻译消息，模板中可以使用 {{t "key" "name" .Name}}
+// 响应带有 Vary: Accept-Language，使用cookie时还会带有 Vary: Cookie
+func I18n(bundle *Bundle, configs ...I18nConfig) HandleFunc {
+	config := DefaultI18nConfig
+	if len(configs) > 0 {
+		config = configs[0]
+	}
+	return func(c *Context) {
+		var candidates []string
+		if config.QueryKey != "" {
+			if lang := c.Query(config.QueryKey); lang != "" {
+				candidates = append(candidates, lang)
+			}
+		}
+		if config.CookieName != "" {
+			if lang, err := c.Cookie(config.CookieName); err == nil && lang != "" {
+				candidates = append(candidates, lang)
+			}
+		}
+		candidates = append(candidates, parseAcceptLanguage(c.Request.Header.Get("Accept-Language"))...)
+		locale := bundle.Match(candidates...)
+		c.Set(localizerKey, &localizer{bundle: bundle, locale: locale})
+		c.SetHeader("Content-Language", locale)
+		c.addVary("Accept-Language")
+		if config.CookieName != "" {
+			c.addVary("Cookie")
+		}
+		c.setTemplateFunc("t", c.T)
+		c.Next()
+	}
+}
+
+// parseAcceptLanguage 按q值从高到低返回Accept-Language中的语言，忽略 *
+func parseAcceptLanguage(header string) []string {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if value := strings.TrimSpace(param); strings.HasPrefix(value, "q=") {
+				if parsed, err := strconv.ParseFloat(value[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			languages = append(languages, language{tag, q})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].q > languages[j].q })
+	tags := make([]string, len(languages))
+	for i, l := range languages {
+		tags[i] = l.tag
+	}
+	return tags
+}
+
+// localizer 当前请求使用的语言
+type localizer struct {
+	bundle *Bundle
+	locale string
+}
+
+// Locale 返回I18n中间件解析出的语言，没有使用中间件时返回空
+func (c *Context) Locale() string {
+	if l, ok := c.Get(localizerKey); ok {
+		return l.(*localizer).locale
+	}
+	return ""
+}
+
+// T 使用当前请求的语言翻译key，没有使用I18n中间件时返回key
+// args可以是一个map，也可以是交替出现的名称与值，如 c.T("items", "count", 3)
+// 参数count用于选择复数形式，消息中的 {name} 会被替换为对应的参数值
+func (c *Context) T(key string, args ...interface{}) string {
+	l, ok := c.Get(localizerKey)
+	if !ok {
+		return key
+	}
+	return l.(*localizer).bundle.Translate(l.(*localizer).locale, key, args...)
+}
@@ -0,0 +1,110 @@
+package aoiweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestBundle(t *testing.T) *Bundle {
+	dir := t.TempDir()
+	files := map[string]string{
+		"en.json":    `{"hello": "Hello {name}", "items": {"zero": "no items", "one": "{count} item", "other": "{count} items"}, "bye": "Bye"}`,
+		"zh-CN.json": `{"hello": "你好 {name}", "items": "{count} 个物品"}`,
+		"ru.json":    `{"items": {"one": "{count} предмет", "few": "{count} предмета", "many": "{count} предметов"}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bundle := NewBundle("en")
+	if err := bundle.LoadMessageFiles(filepath.Join(dir, "*.json")); err != nil {
+		t.Fatal(err)
+	}
+	return bundle
+}
+
+func TestBundleTranslate(t *testing.T) {
+	bundle := newTestBundle(t)
+	tests := []struct {
+		locale string
+		key    string
+		args   []interface{}
+		expect string
+	}{
+		{"en", "hello", []interface{}{"name", "Tom"}, "Hello Tom"},
+		{"zh-CN", "hello", []interface{}{H{"name": "小明"}}, "你好 小明"},
+		{"en", "items", []interface{}{"count", 0}, "no items"},
+		{"en", "items", []interface{}{"count", 1}, "1 item"},
+		{"en", "items", []interface{}{"count", 5}, "5 items"},
+		{"zh-CN", "items", []interface{}{"count", 1}, "1 个物品"},
+		{"ru", "items", []interface{}{"count", 21}, "21 предмет"},
+		{"ru", "items", []interface{}{"count", 3}, "3 предмета"},
+		{"ru", "items", []interface{}{"count", 11}, "11 предметов"},
+		{"zh-CN", "bye", nil, "Bye"},
+		{"en", "missing", nil, "missing"},
+	}
+	for _, test := range tests {
+		if got := bundle.Translate(test.locale, test.key, test.args...); got != test.expect {
+			t.Errorf("%s %s: expect %q, got %q", test.locale, test.key, test.expect, got)
+		}
+	}
+
+	if locale := bundle.Match("zh-Hans-CN", "zh-cn-x-private", "en"); locale != "zh-CN" {
+		t.Fatal("zh-Hans-CN has no match and zh-cn-x-private should fall back to zh-CN, got", locale)
+	}
+	if locale := bundle.Match("ZH_cn"); locale != "zh-CN" {
+		t.Fatal("locale should be matched case-insensitively, got", locale)
+	}
+	if locale := bundle.Match("ru-RU"); locale != "ru" {
+		t.Fatal("region should fall back to language, got", locale)
+	}
+	if locale := bundle.Match("fr"); locale != "en" {
+		t.Fatal("unknown locale should use default, got", locale)
+	}
+}
+
+func TestI18n(t *testing.T) {
+	dir := t.TempDir()
+	page := `{{define "page"}}{{t "hello" "name" .}}|{{t "items" "count" 2}}{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "page.tmpl"), []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	r.LoadHTMLGlob(filepath.Join(dir, "*"))
+	r.Use(I18n(newTestBundle(t)))
+	r.Get("/", func(c *Context) {
+		c.HTML(http.StatusOK, "page", "Tom")
+	})
+	r.Get("/t", func(c *Context) {
+		c.String(http.StatusOK, "%s %s", c.Locale(), c.T("hello", "name", "Tom"))
+	})
+
+	tests := []struct {
+		target string
+		cookie string
+		accept string
+		expect string
+	}{
+		{"/", "", "", "Hello Tom|2 items"},
+		{"/", "", "fr;q=1, zh-CN;q=0.8, en;q=0.5", "你好 Tom|2 个物品"},
+		{"/t?lang=ru", "zh-CN", "en", "ru Hello Tom"},
+		{"/t", "zh-CN", "en", "zh-CN 你好 Tom"},
+		{"/t", "", "zh-TW, *;q=0.1", "en Hello Tom"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", test.target, nil)
+		if test.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "lang", Value: test.cookie})
+		}
+		req.Header.Set("Accept-Language", test.accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != test.expect {
+			t.Errorf("%s: expect %q, got %q", test.target, test.expect, w.Body.String())
+		}
+	}
+}
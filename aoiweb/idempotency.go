@@ -0,0 +1,241 @@
+package aoiweb
+
+import (
+	"AoiFramework/aoicache"
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrIdempotencyConflict 相同幂等键的请求正在处理
+var ErrIdempotencyConflict = errors.New("aoiweb: request with the same idempotency key is in progress")
+
+// IdempotencyStore 保存幂等键对应的响应
+type IdempotencyStore interface {
+	// Begin 开始处理key，返回nil时由当前请求处理；已经完成时返回保存的响应，正在处理时返回ErrIdempotencyConflict
+	Begin(key string, ttl time.Duration) ([]byte, error)
+	// Complete 保存处理完成后的响应，ttl后过期
+	Complete(key string, response []byte, ttl time.Duration) error
+	// Release 放弃处理，之后相同key的请求可以重新处理
+	Release(key string) error
+}
+
+// IdempotencyConfig 幂等中间件的配置
+type IdempotencyConfig struct {
+	Header  string                //携带幂等键的请求头
+	TTL     time.Duration         //响应保存的时间，同时也是处理中标记的最长保留时间
+	KeyFunc func(*Context) string //幂等键所属的客户端，不同客户端的相同键互不影响，为nil时使用IdempotencyScope
+}
+
+// DefaultIdempotencyConfig 默认使用 Idempotency-Key 请求头，响应保存24小时
+var DefaultIdempotencyConfig = IdempotencyConfig{
+	Header:  "Idempotency-Key",
+	TTL:     24 * time.Hour,
+	KeyFunc: IdempotencyScope,
+}
+
+// IdempotencyScope 默认的幂等键范围，使用服务端存储的session时按session区分，否则按客户端IP区分
+func IdempotencyScope(c *Context) string {
+	if _, ok := c.Get(sessionStoreKey); ok {
+		if s := c.Session(); s.ID != "" && !s.IsNew {
+			return "session:" + s.ID
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// idempotencyRecord 保存的幂等记录，Fingerprint为请求体的摘要
+type idempotencyRecord struct {
+	Fingerprint string
+	Response    *cachedResponse
+}
+
+// Idempotency 为携带幂等键的POST与PATCH请求记录第一次的响应，之后相同键的请求直接返回记录的响应
+// 幂等键按KeyFunc返回的客户端、请求方法与路径区分；相同键的请求正在处理时返回409
+// 相同键的请求体与第一次不同时返回422，不会重放之前的响应
+// 处理函数panic、没有写出响应或返回5xx时不保存响应，客户端可以使用相同的键重试
+func Idempotency(store IdempotencyStore, configs ...IdempotencyConfig) HandleFunc {
+	config := DefaultIdempotencyConfig
+	if len(configs) > 0 {
+		config = configs[0]
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = IdempotencyScope
+	}
+	return func(c *Context) {
+		value := c.Request.Header.Get(config.Header)
+		if value == "" || (c.Method != http.MethodPost && c.Method != http.MethodPatch) {
+			c.Next()
+			return
+		}
+		body, err := c.GetRawData()
+		if err != nil {
+			c.Error(err) //请求体超出BodyLimit时为413
+			status := errorStatus(c)
+			c.Fail(status, strconv.Itoa(status)+" "+http.StatusText(status))
+			c.Abort()
+			return
+		}
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+		key := config.KeyFunc(c) + " " + c.Method + " " + c.Request.URL.Path + " " + value
+		data, err := store.Begin(key, config.TTL)
+		if err == ErrIdempotencyConflict {
+			c.Fail(http.StatusConflict, "409 Conflict")
+			c.Abort()
+			return
+		}
+		if err == nil && data != nil {
+			var record *idempotencyRecord
+			if record, err = decodeIdempotencyRecord(data); err == nil {
+				if record.Fingerprint != fingerprint {
+					c.Fail(http.StatusUnprocessableEntity, "422 Unprocessable Entity")
+				} else {
+					c.SetHeader("Idempotent-Replayed", "true")
+					c.writeRecorded(record.Response)
+				}
+				c.Abort()
+				return
+			}
+		}
+		if err != nil {
+			c.Error(err)
+			c.Fail(http.StatusInternalServerError, "500 Internal Server Error")
+			c.Abort()
+			return
+		}
+
+		completed := false
+		defer func() {
+			if !completed { //处理函数panic时释放幂等键
+				_ = store.Release(key)
+			}
+		}()
+		recorded := c.recordResponse()
+		c.writeRecorded(recorded)
+		if recorded.Status == 0 || recorded.Status >= http.StatusInternalServerError {
+			return
+		}
+		if data, err = (&idempotencyRecord{Fingerprint: fingerprint, Response: recorded}).encode(); err == nil {
+			err = store.Complete(key, data, config.TTL)
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		completed = true
+	}
+}
+
+func (record *idempotencyRecord) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(record)
+	return buf.Bytes(), err
+}
+
+func decodeIdempotencyRecord(data []byte) (*idempotencyRecord, error) {
+	record := new(idempotencyRecord)
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(record)
+	return record, err
+}
+
+// idempotencyEntry 内存中保存的幂等记录，response为nil表示正在处理
+type idempotencyEntry struct {
+	response []byte
+	expire   time.Time
+}
+
+// MemoryIdempotencyStore 保存在内存中的幂等记录，只适用于单机部署
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]*idempotencyEntry), lastSweep: time.Now()}
+}
+
+func (s *MemoryIdempotencyStore) Begin(key string, ttl time.Duration) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expire) {
+		if entry.response == nil {
+			return nil, ErrIdempotencyConflict
+		}
+		return entry.response, nil
+	}
+	s.entries[key] = &idempotencyEntry{expire: now.Add(ttl)}
+	return nil, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(key string, response []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = &idempotencyEntry{response: response, expire: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep 每分钟最多清理一次过期的记录
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.entries {
+		if !now.Before(entry.expire) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// CacheIdempotencyStore 使用aoicache保存幂等记录，记录只写入本地缓存
+// 缓存容量不足时记录可能被淘汰，此时相同键的请求会被重新处理
+type CacheIdempotencyStore struct {
+	group *aoicache.Group
+}
+
+func NewCacheIdempotencyStore(group *aoicache.Group) *CacheIdempotencyStore {
+	return &CacheIdempotencyStore{group: group}
+}
+
+func (s *CacheIdempotencyStore) Begin(key string, ttl time.Duration) ([]byte, error) {
+	//GetOrLoad对同一个key只执行一次load，执行了load的请求负责处理，空值表示正在处理
+	owner := false
+	data, err := s.group.GetOrLoad(key, ttl, func(string) ([]byte, error) {
+		owner = true
+		return []byte{}, nil
+	})
+	if err != nil || owner {
+		return nil, err
+	}
+	if data.Len() == 0 {
+		return nil, ErrIdempotencyConflict
+	}
+	return data.ByteSlice(), nil
+}
+
+func (s *CacheIdempotencyStore) Complete(key string, response []byte, ttl time.Duration) error {
+	s.group.Set(key, response, ttl)
+	return nil
+}
+
+func (s *CacheIdempotencyStore) Release(key string) error {
+	s.group.Remove(key)
+	return nil
+}
@@ -0,0 +1,118 @@
+package aoiweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testIdempotency(t *testing.T, store IdempotencyStore) {
+	var calls int32
+	release := make(chan struct{})
+	r := New()
+	r.Use(Idempotency(store))
+	r.Post("/payments", func(c *Context) {
+		n := atomic.AddInt32(&calls, 1)
+		if c.Query("slow") != "" {
+			<-release
+		}
+		if c.Query("fail") != "" && n == 1 {
+			c.Fail(http.StatusInternalServerError, "error")
+			return
+		}
+		c.SetHeader("X-Payment", strconv.Itoa(int(n)))
+		c.String(http.StatusCreated, "payment %d", n)
+	})
+	send := func(target, key, remote, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", target, strings.NewReader(body))
+		req.RemoteAddr = remote
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	do := func(target, key string) *httptest.ResponseRecorder {
+		return send(target, key, "192.0.2.1:1234", "")
+	}
+
+	first := do("/payments", "k1")
+	replay := do("/payments", "k1")
+	if first.Code != http.StatusCreated || replay.Code != http.StatusCreated || replay.Body.String() != "payment 1" ||
+		replay.Header().Get("X-Payment") != "1" || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatal("repeated request should replay the first response, got", replay.Code, replay.Body.String(), replay.Header())
+	}
+	if w := do("/payments", ""); w.Body.String() != "payment 2" {
+		t.Fatal("request without key should not be replayed, got", w.Body.String())
+	}
+
+	//处理中的重复请求返回409
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- do("/payments?slow=1", "k2") }()
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&calls) != 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if w := do("/payments?slow=1", "k2"); w.Code != http.StatusConflict {
+		t.Fatal("concurrent duplicate should return 409, got", w.Code)
+	}
+	close(release)
+	if w := <-done; w.Body.String() != "payment 3" {
+		t.Fatal("original request should complete, got", w.Body.String())
+	}
+
+	//5xx响应不保存，可以使用相同的键重试
+	atomic.StoreInt32(&calls, 0)
+	if w := do("/payments?fail=1", "k3"); w.Code != http.StatusInternalServerError {
+		t.Fatal("first attempt should fail, got", w.Code)
+	}
+	if w := do("/payments?fail=1", "k3"); w.Code != http.StatusCreated || w.Body.String() != "payment 2" {
+		t.Fatal("failed request should be retried, got", w.Code, w.Body.String())
+	}
+
+	//不同客户端的相同键互不影响
+	atomic.StoreInt32(&calls, 0)
+	if w := send("/payments", "k4", "192.0.2.1:1234", "a"); w.Body.String() != "payment 1" {
+		t.Fatal("first client should be processed, got", w.Body.String())
+	}
+	if w := send("/payments", "k4", "192.0.2.2:1234", "a"); w.Body.String() != "payment 2" || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatal("another client should not receive the stored response, got", w.Body.String())
+	}
+	//相同键携带不同的请求体返回422
+	if w := send("/payments", "k4", "192.0.2.1:1234", "b"); w.Code != http.StatusUnprocessableEntity {
+		t.Fatal("reused key with a different payload should return 422, got", w.Code, w.Body.String())
+	}
+	if w := send("/payments", "k4", "192.0.2.1:1234", "a"); w.Body.String() != "payment 1" {
+		t.Fatal("same payload should still be replayed, got", w.Body.String())
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	t.Run("memory", func(t *testing.T) { testIdempotency(t, NewMemoryIdempotencyStore()) })
+	t.Run("aoicache", func(t *testing.T) {
+		testIdempotency(t, NewCacheIdempotencyStore(newCacheGroup("idempotency")))
+	})
+}
+
+func TestMemoryIdempotencyStoreExpire(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	if _, err := store.Begin("k", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Begin("k", time.Millisecond); err != ErrIdempotencyConflict {
+		t.Fatal("key in progress should conflict, got", err)
+	}
+	_ = store.Complete("k", []byte("resp"), time.Millisecond)
+	if data, _ := store.Begin("k", time.Minute); string(data) != "resp" {
+		t.Fatal("completed response should be returned, got", string(data))
+	}
+	time.Sleep(2 * time.Millisecond)
+	if data, err := store.Begin("k", time.Minute); data != nil || err != nil {
+		t.Fatal("expired entry should be processed again", data, err)
+	}
+}
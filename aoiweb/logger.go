@@ -13,6 +13,6 @@ func Logger() HandleFunc {
 		// Process request
 		c.Next()
 		// Calculate resolution time
-		log.Printf("[%d] %s in %v", c.StatusCode, c.Request.RequestURI, time.Since(t))
+		log.Printf("[%d] %s %s in %v", c.StatusCode, c.ClientIP(), c.Request.RequestURI, time.Since(t))
 	}
 }
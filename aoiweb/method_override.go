@@ -0,0 +1,33 @@
+package aoiweb
+
+import (
+	"net/http"
+	"strings"
+)
+
+// overrideMethods MethodOverride允许覆盖的请求方法
+var overrideMethods = map[string]bool{
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// MethodOverride 允许POST请求通过 X-HTTP-Method-Override 请求头或表单字段 _method
+// 指定PUT、PATCH或DELETE方法，用于只能提交GET与POST的html表单
+// 修改请求方法后会重新匹配路由，需要在Engine上注册，且位于依赖请求方法的中间件之前
+func MethodOverride() HandleFunc {
+	return func(c *Context) {
+		if c.Method == http.MethodPost && c.router != nil {
+			method := c.Request.Header.Get("X-HTTP-Method-Override")
+			if method == "" {
+				method = c.Request.PostFormValue("_method")
+			}
+			if method = strings.ToUpper(method); overrideMethods[method] {
+				c.Method = method
+				c.Request.Method = method
+				c.routeHandler = c.router.route(c)
+			}
+		}
+		c.Next()
+	}
+}
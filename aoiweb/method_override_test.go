@@ -0,0 +1,67 @@
+package aoiweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMethodOverride(t *testing.T) {
+	r := New()
+	r.Use(MethodOverride())
+	r.Post("/posts/:id", func(c *Context) { c.String(http.StatusOK, "post %s", c.Param("id")) })
+	r.Delete("/posts/:id", func(c *Context) { c.String(http.StatusOK, "delete %s", c.Param("id")) })
+	r.Put("/posts/:id", func(c *Context) { c.String(http.StatusOK, "put %s", c.Param("id")) })
+
+	tests := []struct {
+		name   string
+		req    func() *http.Request
+		status int
+		body   string
+	}{
+		{"form field", func() *http.Request {
+			req := httptest.NewRequest("POST", "/posts/1", strings.NewReader("_method=delete"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			return req
+		}, http.StatusOK, "delete 1"},
+		{"header", func() *http.Request {
+			req := httptest.NewRequest("POST", "/posts/2", nil)
+			req.Header.Set("X-HTTP-Method-Override", "PUT")
+			return req
+		}, http.StatusOK, "put 2"},
+		{"unsupported method", func() *http.Request {
+			req := httptest.NewRequest("POST", "/posts/3", nil)
+			req.Header.Set("X-HTTP-Method-Override", "GET")
+			return req
+		}, http.StatusOK, "post 3"},
+		{"only post", func() *http.Request {
+			req := httptest.NewRequest("GET", "/posts/4", nil)
+			req.Header.Set("X-HTTP-Method-Override", "DELETE")
+			return req
+		}, http.StatusNotFound, "404 NOT FOUND /posts/4 \n"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, test.req())
+		if w.Code != test.status || w.Body.String() != test.body {
+			t.Errorf("%s: unexpected response %d %q", test.name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestMethodOverrideParams(t *testing.T) {
+	r := New()
+	r.Use(MethodOverride())
+	r.Post("/posts/:id/comments", func(c *Context) { c.String(http.StatusOK, "post %s", c.Param("id")) })
+	r.Put("/posts/:slug/*rest", func(c *Context) {
+		c.String(http.StatusOK, "put %s %s %q %s", c.Param("slug"), c.Param("rest"), c.Param("id"), c.FullPath())
+	})
+	req := httptest.NewRequest("POST", "/posts/hello/comments", nil)
+	req.Header.Set("X-HTTP-Method-Override", "PUT")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if expect := `put hello comments "" /posts/:slug/*rest`; w.Body.String() != expect {
+		t.Fatalf("params should be matched again, expect %s, got %s", expect, w.Body.String())
+	}
+}
@@ -0,0 +1,68 @@
+package aoiweb
+
+import (
+	"AoiFramework/metrics"
+	"strconv"
+	"time"
+)
+
+// httpMetrics 注册在同一个注册表中的http指标
+type httpMetrics struct {
+	requestsTotal    *metrics.Counter
+	requestsInFlight *metrics.Gauge
+	requestDuration  *metrics.Histogram
+	responseSize     *metrics.Histogram
+}
+
+// registryOrDefault reg为nil时使用默认注册表
+func registryOrDefault(reg *metrics.Registry) *metrics.Registry {
+	if reg == nil {
+		return metrics.DefaultRegistry
+	}
+	return reg
+}
+
+// httpMetricsOf 返回注册在reg中的http指标，多次调用共用同一组数据
+func httpMetricsOf(reg *metrics.Registry) *httpMetrics {
+	return &httpMetrics{
+		requestsTotal: reg.NewCounter("aoiweb_http_requests_total",
+			"Total number of HTTP requests.", "method", "route", "status"),
+		requestsInFlight: reg.NewGauge("aoiweb_http_requests_in_flight",
+			"Number of HTTP requests being served.", "method"),
+		requestDuration: reg.NewHistogram("aoiweb_http_request_duration_seconds",
+			"HTTP request latency in seconds.", metrics.DefBuckets, "method", "route", "status"),
+		responseSize: reg.NewHistogram("aoiweb_http_response_size_bytes",
+			"HTTP response size in bytes.", []float64{100, 1000, 10000, 100000, 1000000}, "method", "route", "status"),
+	}
+}
+
+// Metrics 将请求数、处理中的请求数、耗时与响应大小记录到reg，reg为nil时使用metrics.DefaultRegistry
+// 标签使用路由规则而不是原始路径，避免参数导致标签数量无限增长
+// 多个Engine需要分开统计时应当各自使用独立的注册表
+func Metrics(reg *metrics.Registry) HandleFunc {
+	m := httpMetricsOf(registryOrDefault(reg))
+	return func(c *Context) {
+		start := time.Now()
+		m.requestsInFlight.Inc(c.Method)
+		defer m.requestsInFlight.Dec(c.Method)
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched" //未匹配的请求统一归类
+		}
+		status := strconv.Itoa(c.writer.status/100) + "xx"
+		m.requestsTotal.Inc(c.Method, route, status)
+		m.requestDuration.Observe(time.Since(start).Seconds(), c.Method, route, status)
+		m.responseSize.Observe(float64(c.writer.size), c.Method, route, status)
+	}
+}
+
+// ExposeMetrics 在指定路径以Prometheus文本格式输出reg中的所有指标，reg为nil时使用metrics.DefaultRegistry
+func (e *Engine) ExposeMetrics(path string, reg *metrics.Registry) {
+	reg = registryOrDefault(reg)
+	e.Get(path, func(c *Context) {
+		reg.ServeHTTP(c.Writer, c.Request)
+	})
+}
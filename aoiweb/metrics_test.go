@@ -0,0 +1,52 @@
+package aoiweb
+
+import (
+	"AoiFramework/metrics"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := metrics.NewRegistry()
+	r := New()
+	r.Use(Metrics(reg))
+	r.ExposeMetrics("/metrics", reg)
+	r.Get("/user/:name", func(c *Context) {
+		c.String(http.StatusOK, "hello %s", c.Param("name"))
+	})
+
+	for _, path := range []string{"/user/tom", "/user/sam", "/missing"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	r.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	for _, line := range []string{
+		`aoiweb_http_requests_total{method="GET",route="/user/:name",status="2xx"} 2`,
+		`aoiweb_http_requests_total{method="GET",route="unmatched",status="4xx"} 1`,
+		`aoiweb_http_request_duration_seconds_count{method="GET",route="/user/:name",status="2xx"} 2`,
+		`aoiweb_http_requests_in_flight{method="GET"} 1`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("metrics output should contain %q", line)
+		}
+	}
+}
+
+func TestMetricsRegistryFallback(t *testing.T) {
+	//使用默认注册表时多次调用共用同一组指标，不会重复注册
+	_ = Metrics(nil)
+	_ = Metrics(nil)
+	r := New()
+	r.ExposeMetrics("/metrics", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(w.Body.String(), "# TYPE aoiweb_http_requests_total counter") {
+		t.Fatal("default registry should be exposed, got", w.Body.String())
+	}
+}
@@ -0,0 +1,69 @@
+package aoiweb
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// WrapH 将http.Handler转换为HandleFunc
+func WrapH(handler http.Handler) HandleFunc {
+	return func(c *Context) {
+		handler.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// WrapF 将http.HandlerFunc转换为HandleFunc
+func WrapF(handler http.HandlerFunc) HandleFunc {
+	return WrapH(handler)
+}
+
+// Mount 将handler挂载到分组下的prefix，prefix及其下的所有路径、所有请求方法都交给handler处理
+// handler收到的请求路径会去除分组前缀与prefix，例如挂载到 /cache 时 /cache/group/key 变为 /group/key
+// handler可以是另一个Engine，此时子应用的中间件与路由都照常生效，路径修正的重定向地址会加上去除的前缀
+func (group *RouterGroup) Mount(prefix string, handler http.Handler) {
+	relativePath := strings.TrimSuffix(path.Join("/", prefix), "/")
+	handle := stripPrefix(group.prefix+relativePath, handler)
+	if relativePath == "" {
+		group.Any("/", handle)
+	} else {
+		group.Any(relativePath, handle)
+	}
+	group.Any(relativePath+"/*filepath", handle)
+}
+
+// mountPrefixKey 在请求的context中保存挂载时去除的路径前缀
+type mountPrefixKey struct{}
+
+// mountPrefix 返回请求经过Mount时去除的前缀，多层挂载时为所有前缀的拼接
+// 子应用生成站内重定向地址时需要加上该前缀
+func mountPrefix(request *http.Request) string {
+	prefix, _ := request.Context().Value(mountPrefixKey{}).(string)
+	return prefix
+}
+
+// stripPrefix 去除请求路径的前缀后交给handler，不修改上下文中的原始请求
+// 去除的前缀保存在请求的context中
+func stripPrefix(prefix string, handler http.Handler) HandleFunc {
+	return func(c *Context) {
+		ctx := context.WithValue(c.Request.Context(), mountPrefixKey{}, mountPrefix(c.Request)+prefix)
+		request := c.Request.WithContext(ctx)
+		u := *c.Request.URL
+		u.Path = trimPathPrefix(u.Path, prefix)
+		if u.RawPath != "" {
+			u.RawPath = trimPathPrefix(u.RawPath, prefix)
+		}
+		request.URL = &u
+		handler.ServeHTTP(c.Writer, request)
+	}
+}
+
+// trimPathPrefix 去除前缀并保证结果以 / 开头
+func trimPathPrefix(p, prefix string) string {
+	p = strings.TrimPrefix(p, prefix)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
@@ -0,0 +1,86 @@
+package aoiweb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	sub := New()
+	sub.Use(func(c *Context) {
+		c.SetHeader("X-Sub", "1")
+		c.Next()
+	})
+	sub.Get("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "user %s %s", c.Param("id"), c.FullPath())
+	})
+
+	r := New()
+	v1 := r.Group("/v1")
+	v1.Use(func(c *Context) {
+		c.SetHeader("X-Parent", "1")
+		c.Next()
+	})
+	v1.Mount("/app", sub)
+	r.Mount("/raw", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "%s %s %s", req.Method, req.URL.Path, req.URL.RawQuery)
+	}))
+	r.Get("/wrap", WrapF(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, req.URL.Path)
+	}))
+
+	tests := []struct {
+		method string
+		target string
+		status int
+		body   string
+		sub    bool
+	}{
+		{"GET", "/v1/app/users/7", http.StatusOK, "user 7 /users/:id", true},
+		{"GET", "/v1/app/missing", http.StatusNotFound, "404 NOT FOUND /missing \n", true},
+		{"DELETE", "/raw/a/b?x=1", http.StatusOK, "DELETE /a/b x=1", false},
+		{"POST", "/raw", http.StatusOK, "POST / ", false},
+		{"GET", "/wrap", http.StatusOK, "/wrap", false},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(test.method, test.target, nil))
+		if w.Code != test.status || w.Body.String() != test.body {
+			t.Errorf("%s %s: unexpected response %d %q", test.method, test.target, w.Code, w.Body.String())
+		}
+		if test.sub && (w.Header().Get("X-Sub") != "1" || w.Header().Get("X-Parent") != "1") {
+			t.Errorf("%s: both parent and sub middlewares should run, got %v", test.target, w.Header())
+		}
+	}
+}
+
+func TestMountRedirect(t *testing.T) {
+	sub := New()
+	sub.RedirectTrailingSlash = true
+	sub.RedirectFixedPath = true
+	sub.Get("/users", func(c *Context) { c.String(http.StatusOK, "users") })
+
+	r := New()
+	r.Group("/v1").Mount("/api", sub)
+	nested := New()
+	nested.Mount("/app", r)
+
+	tests := []struct {
+		engine   *Engine
+		target   string
+		location string
+	}{
+		{r, "/v1/api/users/", "/v1/api/users"},
+		{r, "/v1/api/Users?page=2", "/v1/api/users?page=2"},
+		{nested, "/app/v1/api/users/", "/app/v1/api/users"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		test.engine.ServeHTTP(w, httptest.NewRequest("GET", test.target, nil))
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != test.location {
+			t.Errorf("%s: expect redirect to %s, got %d %q", test.target, test.location, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
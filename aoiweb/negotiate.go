@@ -0,0 +1,114 @@
+package aoiweb
+
+import (
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	MIMEJSON = "application/json"
+	MIMEXML  = "application/xml"
+	MIMEXML2 = "text/xml"
+)
+
+// acceptRange Accept请求头中的一项
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept 解析Accept请求头，按q值从高到低排序，q值相同时保持原有顺序
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	return ranges
+}
+
+// NegotiateFormat 根据Accept请求头从offered中选择响应格式，没有Accept时返回第一个，都不接受时返回空
+// 响应会带有 Vary: Accept，缓存需要按Accept区分
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	c.addVary("Accept")
+	accept := c.Request.Header.Get("Accept")
+	if accept == "" {
+		return offered[0]
+	}
+	for _, r := range parseAccept(accept) {
+		for _, format := range offered {
+			if matchMediaType(r.mediaType, format) {
+				return format
+			}
+		}
+	}
+	return ""
+}
+
+// addVary 在Vary响应头中加上name，已经存在时不重复添加
+func (c *Context) addVary(name string) {
+	header := c.Writer.Header()
+	for _, value := range header.Values("Vary") {
+		for _, existing := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(existing), name) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", name)
+}
+
+// matchMediaType 判断Accept中的媒体类型是否匹配format，支持 */* 与 type/* 形式
+func matchMediaType(accept, format string) bool {
+	if accept == "*/*" || accept == format {
+		return true
+	}
+	if strings.HasSuffix(accept, "/*") {
+		return strings.HasPrefix(format, strings.TrimSuffix(accept, "*"))
+	}
+	return false
+}
+
+// XML 返回xml格式的响应
+func (c *Context) XML(code int, obj interface{}) {
+	c.renderXML(code, MIMEXML, obj)
+}
+
+func (c *Context) renderXML(code int, contentType string, obj interface{}) {
+	c.SetHeader("Content-Type", contentType)
+	c.Status(code)
+	if err := xml.NewEncoder(c.Writer).Encode(obj); err != nil {
+		http.Error(c.Writer, err.Error(), 500)
+	}
+}
+
+// Negotiate 根据Accept请求头选择json或xml格式返回obj，客户端都不接受时返回406
+func (c *Context) Negotiate(code int, obj interface{}) {
+	switch c.NegotiateFormat(MIMEJSON, MIMEXML, MIMEXML2) {
+	case MIMEJSON:
+		c.JSON(code, obj)
+	case MIMEXML, MIMEXML2:
+		c.XML(code, obj)
+	default:
+		c.AbortWithStatus(http.StatusNotAcceptable)
+	}
+}
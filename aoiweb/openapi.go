@@ -0,0 +1,335 @@
+package aoiweb
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Route 一条已注册的路由，可以通过链式调用补充生成OpenAPI文档所需的信息
+type Route struct {
+	Method string
+	Path   string
+
+	summary     string
+	description string
+	tags        []string
+	request     reflect.Type
+	responses   map[int]reflect.Type
+	hidden      bool
+}
+
+// Summary 设置接口的简要说明
+func (r *Route) Summary(summary string) *Route {
+	r.summary = summary
+	return r
+}
+
+// Description 设置接口的详细说明
+func (r *Route) Description(description string) *Route {
+	r.description = description
+	return r
+}
+
+// Tags 设置接口的分类标签
+func (r *Route) Tags(tags ...string) *Route {
+	r.tags = append(r.tags, tags...)
+	return r
+}
+
+// Request 设置请求类型，与ShouldBind使用相同的标签：path、query、header标签的字段作为参数，其余字段作为json请求体
+func (r *Route) Request(obj interface{}) *Route {
+	r.request = indirectType(reflect.TypeOf(obj))
+	return r
+}
+
+// Response 设置响应码对应的响应类型，obj为nil时表示没有响应体
+func (r *Route) Response(code int, obj interface{}) *Route {
+	if r.responses == nil {
+		r.responses = make(map[int]reflect.Type)
+	}
+	r.responses[code] = indirectType(reflect.TypeOf(obj))
+	return r
+}
+
+// Hide 不在OpenAPI文档中显示该路由
+func (r *Route) Hide() *Route {
+	r.hidden = true
+	return r
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// OpenAPIInfo 文档的基本信息
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIDocument OpenAPI 3 文档，只包含根据路由生成的部分
+type OpenAPIDocument struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       OpenAPIInfo                      `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Operation 一个请求方法对应的接口
+type Operation struct {
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema json schema的子集
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// OpenAPI 根据所有已注册的路由生成OpenAPI 3文档
+func (e *Engine) OpenAPI(info OpenAPIInfo) *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI:    "3.0.3",
+		Info:       info,
+		Paths:      make(map[string]map[string]*Operation),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}
+	routers := []*router{e.router}
+	for _, host := range e.hosts {
+		routers = append(routers, host.router)
+	}
+	for _, r := range routers {
+		for _, ri := range r.routes {
+			route := r.docs[ri.Method+"-"+ri.Path]
+			if route == nil || route.hidden {
+				continue
+			}
+			path, params := openAPIPath(route.Path)
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = make(map[string]*Operation)
+			}
+			doc.Paths[path][strings.ToLower(route.Method)] = doc.operation(route, params)
+		}
+	}
+	return doc
+}
+
+// ExposeOpenAPI 在指定路径以json格式输出OpenAPI文档，文档在每次请求时根据当前的路由生成
+func (e *Engine) ExposeOpenAPI(path string, info OpenAPIInfo) {
+	e.Get(path, func(c *Context) {
+		c.JSON(http.StatusOK, e.OpenAPI(info))
+	}).Hide()
+}
+
+// openAPIPath 将 /users/:id 形式的路由转换为 /users/{id}，并返回路径参数
+func openAPIPath(pattern string) (string, []string) {
+	var params []string
+	parts := parsePattern(pattern)
+	for i, part := range parts {
+		if part[0] == ':' || part[0] == '*' {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	path := "/" + strings.Join(parts, "/")
+	if strings.HasSuffix(pattern, "/") && path != "/" {
+		path += "/"
+	}
+	return path, params
+}
+
+func (doc *OpenAPIDocument) operation(route *Route, pathParams []string) *Operation {
+	op := &Operation{
+		Summary:     route.summary,
+		Description: route.description,
+		Tags:        route.tags,
+		Responses:   make(map[string]*Response),
+	}
+
+	declared := make(map[string]bool)
+	if route.request != nil && route.request.Kind() == reflect.Struct {
+		var body []reflect.StructField
+		for _, field := range structFields(route.request) {
+			in, name := parameterLocation(field)
+			if in == "" {
+				body = append(body, field)
+				continue
+			}
+			if in == "path" {
+				declared[name] = true
+			}
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:     name,
+				In:       in,
+				Required: in == "path" || field.Tag.Get("binding") == "required",
+				Schema:   doc.schema(field.Type),
+			})
+		}
+		if len(body) > 0 && route.Method != http.MethodGet && route.Method != http.MethodHead {
+			schema := doc.objectSchema(body)
+			op.RequestBody = &RequestBody{
+				Required: len(schema.Required) > 0,
+				Content:  map[string]*MediaType{MIMEJSON: {Schema: schema}},
+			}
+		}
+	}
+	//请求类型中没有声明的路径参数按字符串处理
+	for _, name := range pathParams {
+		if !declared[name] {
+			op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+
+	if len(route.responses) == 0 {
+		op.Responses["200"] = &Response{Description: http.StatusText(http.StatusOK)}
+	}
+	for code, typ := range route.responses {
+		response := &Response{Description: http.StatusText(code)}
+		if typ != nil {
+			response.Content = map[string]*MediaType{MIMEJSON: {Schema: doc.schema(typ)}}
+		}
+		op.Responses[strconv.Itoa(code)] = response
+	}
+	return op
+}
+
+// parameterLocation 返回字段作为参数时的位置与名称，作为请求体字段时返回空
+func parameterLocation(field reflect.StructField) (string, string) {
+	for _, in := range []string{"path", "query", "header"} {
+		if name := strings.Split(field.Tag.Get(in), ",")[0]; name != "" && name != "-" {
+			return in, name
+		}
+	}
+	return "", ""
+}
+
+// structFields 返回结构体的导出字段，匿名嵌入的结构体字段会被展开
+func structFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			fields = append(fields, structFields(indirectType(field.Type))...)
+			continue
+		}
+		if field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema 根据类型生成schema，具名结构体放入components中并返回引用
+func (doc *OpenAPIDocument) schema(t reflect.Type) *Schema {
+	nullable := false
+	if t.Kind() == reflect.Ptr {
+		t, nullable = indirectType(t), true
+	}
+	var schema *Schema
+	switch {
+	case t == timeType:
+		schema = &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name := t.Name()
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			doc.Components.Schemas[name] = nil //先占位，避免递归类型无限展开
+			doc.Components.Schemas[name] = doc.objectSchema(structFields(t))
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	case t.Kind() == reflect.Struct:
+		schema = doc.objectSchema(structFields(t))
+	default:
+		schema = doc.basicSchema(t)
+	}
+	schema.Nullable = nullable
+	return schema
+}
+
+func (doc *OpenAPIDocument) basicSchema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 { //[]byte按base64编码
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: doc.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: doc.schema(t.Elem())}
+	}
+	return &Schema{}
+}
+
+// objectSchema 按json标签生成对象的schema，binding:"required" 的字段为必填
+func (doc *OpenAPIDocument) objectSchema(fields []reflect.StructField) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, field := range fields {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = doc.schema(field.Type)
+		if field.Tag.Get("binding") == "required" {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
@@ -0,0 +1,91 @@
+package aoiweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type openAPIUser struct {
+	ID        int64          `json:"id"`
+	Name      string         `json:"name" binding:"required"`
+	Email     *string        `json:"email,omitempty"`
+	Friends   []*openAPIUser `json:"friends"`
+	Labels    map[string]int `json:"labels"`
+	CreatedAt time.Time      `json:"created_at"`
+	Password  string         `json:"-"`
+}
+
+type updateUserRequest struct {
+	ID     int64  `path:"id"`
+	DryRun bool   `query:"dry_run"`
+	Token  string `header:"X-Token" binding:"required"`
+	Name   string `json:"name" binding:"required"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	r := New()
+	r.Put("/users/:id", func(c *Context) {}).
+		Summary("update user").Tags("user").
+		Request(updateUserRequest{}).
+		Response(http.StatusOK, &openAPIUser{}).
+		Response(http.StatusNoContent, nil)
+	r.Get("/files/*filepath", func(c *Context) {})
+	r.ExposeOpenAPI("/openapi.json", OpenAPIInfo{Title: "test", Version: "1.0"})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	var doc map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"openapi":                       "3.0.3",
+		"info.title":                    "test",
+		"paths./users/{id}.put.summary": "update user",
+		"paths./users/{id}.put.parameters.0.schema.format":                             "int64",
+		"paths./users/{id}.put.parameters.1.in":                                        "query",
+		"paths./users/{id}.put.parameters.2.required":                                  true,
+		"paths./users/{id}.put.requestBody.content.application/json.schema.required.0": "name",
+		"paths./users/{id}.put.responses.200.content.application/json.schema.$ref":     "#/components/schemas/openAPIUser",
+		"paths./users/{id}.put.responses.204.description":                              "No Content",
+		"paths./files/{filepath}.get.parameters.0.in":                                  "path",
+		"paths./files/{filepath}.get.responses.200.description":                        "OK",
+		"components.schemas.openAPIUser.required.0":                                    "name",
+		"components.schemas.openAPIUser.properties.email.nullable":                     true,
+		"components.schemas.openAPIUser.properties.friends.items.$ref":                 "#/components/schemas/openAPIUser",
+		"components.schemas.openAPIUser.properties.labels.additionalProperties.type":   "integer",
+		"components.schemas.openAPIUser.properties.created_at.format":                  "date-time",
+	}
+	for path, value := range expect {
+		if got := lookupJSON(doc, path); got != value {
+			t.Errorf("%s: expect %v, got %v", path, value, got)
+		}
+	}
+	if _, ok := doc["paths"].(map[string]interface{})["/openapi.json"]; ok || lookupJSON(doc, "components.schemas.openAPIUser.properties.Password") != nil {
+		t.Fatal("hidden route and ignored fields should not be documented")
+	}
+}
+
+// lookupJSON 按点号分隔的路径查找值，数组使用下标
+func lookupJSON(value interface{}, path string) interface{} {
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index >= len(v) {
+				return nil
+			}
+			value = v[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
@@ -0,0 +1,124 @@
+package aoiweb
+
+import (
+	"path"
+	"strings"
+)
+
+// cleanPath 清理路径中多余的 /、. 与 ..，保留末尾的 /
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// canonicalPath 根据匹配到的路由规则与请求路径的各部分生成规范路径
+// 末尾的 / 与路由规则保持一致，*通配的部分保持请求中的形式
+func canonicalPath(pattern string, parts []string, trailingSlash bool) string {
+	segments := make([]string, 0, len(parts))
+	wildcard := false
+	for i, part := range parsePattern(pattern) {
+		switch part[0] {
+		case ':':
+			segments = append(segments, parts[i])
+		case '*':
+			segments = append(segments, parts[i:]...)
+			wildcard = true
+		default:
+			segments = append(segments, part)
+		}
+		if wildcard {
+			break
+		}
+	}
+	p := "/" + strings.Join(segments, "/")
+	if !wildcard {
+		trailingSlash = strings.HasSuffix(pattern, "/")
+	}
+	if trailingSlash && p != "/" {
+		p += "/"
+	}
+	return p
+}
+
+// fixedPath 根据引擎的配置返回需要重定向到的路径，不需要重定向时返回false
+// 开启RedirectFixedPath时使用清理后的路径重新匹配，仍未匹配时忽略大小写
+func (r *router) fixedPath(c *Context, route *node) (string, bool) {
+	e := c.engine
+	if e == nil || (!e.RedirectTrailingSlash && !e.RedirectFixedPath) {
+		return "", false
+	}
+	requestPath := c.Path
+	if e.RedirectFixedPath {
+		requestPath = cleanPath(requestPath)
+		if route, _ = r.getRoute(c.Method, requestPath); route == nil {
+			route = r.searchFold(c.Method, requestPath)
+		}
+	}
+	if route == nil {
+		return "", false
+	}
+	fixed := canonicalPath(route.pattern, parsePattern(requestPath), strings.HasSuffix(requestPath, "/"))
+	switch {
+	case fixed == c.Path || !safeRedirectPath(fixed):
+		return "", false
+	case e.RedirectFixedPath:
+		return fixed, true
+	}
+	//只开启RedirectTrailingSlash时，仅处理末尾 / 不同的情况
+	if strings.TrimSuffix(fixed, "/") == strings.TrimSuffix(c.Path, "/") {
+		return fixed, true
+	}
+	return "", false
+}
+
+// safeRedirectPath 判断路径能否作为站内重定向地址
+// 第一段以 / 或 \ 开头时浏览器会将其视为 //host 形式的外部地址，不进行重定向
+func safeRedirectPath(p string) bool {
+	return strings.HasPrefix(p, "/") && (len(p) == 1 || (p[1] != '/' && p[1] != '\\'))
+}
+
+// searchFold 忽略静态部分的大小写查找路由
+func (r *router) searchFold(method, p string) *node {
+	root, ok := r.roots[method]
+	if !ok {
+		return nil
+	}
+	return root.searchFold(parsePattern(p), 0)
+}
+
+// searchFold 与search相同，但静态部分不区分大小写
+func (n *node) searchFold(parts []string, height int) *node {
+	if len(parts) == height || strings.HasPrefix(n.part, "*") {
+		if n.pattern == "" {
+			return nil
+		}
+		return n
+	}
+	part := parts[height]
+	for _, child := range n.children {
+		if child.isWild || strings.EqualFold(child.part, part) {
+			if result := child.searchFold(parts, height+1); result != nil {
+				return result
+			}
+		}
+	}
+	return nil
+}
+
+// redirectFixedPath 重定向到修正后的路径，GET请求使用301，其余使用308以保留请求方法与请求体
+// 引擎通过Mount挂载时，重定向地址加上挂载时去除的前缀
+func redirectFixedPath(location string) HandleFunc {
+	return func(c *Context) {
+		target := mountPrefix(c.Request) + location
+		if c.Request.URL.RawQuery != "" {
+			target += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(redirectCode(c.Method, true), target)
+	}
+}
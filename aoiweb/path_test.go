@@ -0,0 +1,120 @@
+package aoiweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := map[string]string{
+		"":            "/",
+		"/":           "/",
+		"a/b":         "/a/b",
+		"/a//b/":      "/a/b/",
+		"/a/./b/../c": "/a/c",
+		"/../a":       "/a",
+	}
+	for p, expect := range tests {
+		if got := cleanPath(p); got != expect {
+			t.Errorf("cleanPath(%q): expect %q, got %q", p, expect, got)
+		}
+	}
+}
+
+func newPathEngine() *Engine {
+	r := New()
+	r.Get("/users/:name", func(c *Context) { c.String(http.StatusOK, "user %s", c.Param("name")) })
+	r.Post("/users/:name", func(c *Context) { c.String(http.StatusOK, "post %s", c.Param("name")) })
+	r.Get("/docs/", func(c *Context) { c.String(http.StatusOK, "docs") })
+	r.Get("/static/*filepath", func(c *Context) { c.String(http.StatusOK, "file %s", c.Param("filepath")) })
+	return r
+}
+
+func TestRedirectPath(t *testing.T) {
+	r := newPathEngine()
+	r.RedirectTrailingSlash = true
+
+	type redirectTest struct {
+		method   string
+		target   string
+		status   int
+		location string
+	}
+	tests := []redirectTest{
+		{"GET", "/users/tom/", http.StatusMovedPermanently, "/users/tom"},
+		{"GET", "/docs?page=2", http.StatusMovedPermanently, "/docs/?page=2"},
+		{"POST", "/users/tom/", http.StatusPermanentRedirect, "/users/tom"},
+		{"GET", "/users//tom", http.StatusOK, ""},
+		{"GET", "/static/css/", http.StatusOK, ""},
+		{"GET", "/USERS/tom", http.StatusNotFound, ""},
+	}
+	check := func(r *Engine) {
+		for _, test := range tests {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(test.method, test.target, nil))
+			if w.Code != test.status || w.Header().Get("Location") != test.location {
+				t.Errorf("%s %s: unexpected response %d %q", test.method, test.target, w.Code, w.Header().Get("Location"))
+			}
+		}
+	}
+	check(r)
+
+	r.RedirectFixedPath = true
+	tests[3].status, tests[3].location = http.StatusMovedPermanently, "/users/tom"
+	tests[5].status, tests[5].location = http.StatusMovedPermanently, "/users/tom"
+	tests = append(tests, redirectTest{"GET", "/Docs/../docs", http.StatusMovedPermanently, "/docs/"})
+	check(r)
+}
+
+func TestRemoveExtraSlash(t *testing.T) {
+	r := newPathEngine()
+	r.RemoveExtraSlash = true
+	r.RedirectTrailingSlash = true
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users//./tom", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "user tom" {
+		t.Fatal("extra slashes should be removed without redirect, got", w.Code, w.Body.String())
+	}
+}
+
+func TestUseRawPath(t *testing.T) {
+	r := New()
+	r.Get("/files/:name", func(c *Context) { c.String(http.StatusOK, "file %s", c.Param("name")) })
+
+	serve := func() (int, string) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/files/a%2Fb", nil))
+		return w.Code, w.Body.String()
+	}
+	if code, _ := serve(); code != http.StatusNotFound {
+		t.Fatal("escaped slash should split the path without UseRawPath, got", code)
+	}
+	r.UseRawPath = true
+	if code, body := serve(); code != http.StatusOK || body != "file a/b" {
+		t.Fatal("escaped slash should be kept in param, got", code, body)
+	}
+	r.UnescapePathValues = false
+	if code, body := serve(); code != http.StatusOK || body != "file a%2Fb" {
+		t.Fatal("param should stay escaped, got", code, body)
+	}
+}
+
+func TestRedirectPathOpenRedirect(t *testing.T) {
+	r := New()
+	r.RedirectTrailingSlash = true
+	r.RedirectFixedPath = true
+	r.Get("/:name", func(c *Context) { c.String(http.StatusOK, "name %s", c.Param("name")) })
+	for _, target := range []string{"/\\evil.com/", "//evil.com/", "/\\/evil.com/"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.URL.Path = target
+		r.ServeHTTP(w, req)
+		if location := w.Header().Get("Location"); location != "" && !safeRedirectPath(location) {
+			t.Errorf("%s: redirect to external location %q", target, location)
+		}
+	}
+	if safeRedirectPath("/\\evil.com") || safeRedirectPath("//evil.com") || !safeRedirectPath("/evil.com") {
+		t.Fatal("unexpected safeRedirectPath result")
+	}
+}
@@ -0,0 +1,279 @@
+package aoiweb
+
+import (
+	"AoiFramework/aoirpc/xclient"
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BalancePolicy 选择上游服务的负载均衡策略
+type BalancePolicy int
+
+const (
+	RoundRobin       BalancePolicy = iota //轮询
+	Random                                //随机
+	LeastConnections                      //当前连接数最少
+)
+
+// ProxyConfig 反向代理的配置
+type ProxyConfig struct {
+	//连续失败MaxFails次后，上游在FailTimeout内不再被选择；所有上游都被摘除时仍然使用全部上游
+	MaxFails    int
+	FailTimeout time.Duration
+	//GET、HEAD等幂等且没有请求体的请求，在连接上游失败时换一个上游重试的次数
+	Retries int
+	//Transport 请求上游使用的RoundTripper，为nil时使用http.DefaultTransport
+	Transport http.RoundTripper
+	//Rewrite 发送前修改请求，可以用于改写路径与请求头
+	Rewrite func(req *http.Request)
+	//ModifyResponse 返回客户端前修改上游的响应
+	ModifyResponse func(resp *http.Response) error
+}
+
+// DefaultProxyConfig 默认的反向代理配置
+var DefaultProxyConfig = ProxyConfig{
+	MaxFails:    3,
+	FailTimeout: 30 * time.Second,
+	Retries:     2,
+}
+
+// upstream 一个上游服务及其状态
+type upstream struct {
+	target *url.URL
+	proxy  *httputil.ReverseProxy
+	active int64 //正在处理的请求数
+
+	mu           sync.Mutex
+	fails        int
+	ejectedUntil time.Time
+}
+
+// available 上游是否没有被摘除
+func (u *upstream) available(now time.Time) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return !now.Before(u.ejectedUntil)
+}
+
+// report 记录一次请求的结果，连续失败达到maxFails次时摘除上游
+func (u *upstream) report(ok bool, maxFails int, failTimeout time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if ok {
+		u.fails = 0
+		return
+	}
+	u.fails++
+	if maxFails > 0 && u.fails >= maxFails {
+		u.fails = 0
+		u.ejectedUntil = time.Now().Add(failTimeout)
+	}
+}
+
+// proxyErrorKey 通过请求的context传递上游请求的错误
+type proxyErrorKey struct{}
+
+// reverseProxy 保存上游列表与负载均衡状态
+type reverseProxy struct {
+	discovery xclient.Discovery
+	policy    BalancePolicy
+	config    ProxyConfig
+
+	mu        sync.Mutex
+	upstreams map[string]*upstream
+	counter   uint64
+	rand      *rand.Rand
+}
+
+// Proxy 返回将请求转发到上游服务的处理函数
+// targets可以使用 xclient.NewMultiServerDiscovery 传入固定的地址列表，
+// 也可以使用 xclient.NewAoiRegistryDiscovery 从aoirpc注册中心获取服务列表
+// 地址可以是完整的url，也可以是注册中心使用的 protocol@addr 形式，http与https之外的协议都按http处理
+// 上游连接失败或返回502、503、504时计为一次失败；支持websocket等协议升级请求
+func Proxy(targets xclient.Discovery, policy BalancePolicy, configs ...ProxyConfig) HandleFunc {
+	config := DefaultProxyConfig
+	if len(configs) > 0 {
+		config = configs[0]
+	}
+	p := &reverseProxy{
+		discovery: targets,
+		policy:    policy,
+		config:    config,
+		upstreams: make(map[string]*upstream),
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	return p.handle
+}
+
+func (p *reverseProxy) handle(c *Context) {
+	retries := 0
+	if isIdempotent(c.Request.Method) && (c.Request.Body == nil || c.Request.Body == http.NoBody || c.Request.ContentLength == 0) {
+		retries = p.config.Retries
+	}
+	tried := make(map[*upstream]bool)
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		u, err := p.pick(tried)
+		if err != nil {
+			if lastErr == nil {
+				c.Error(err)
+				c.Fail(http.StatusServiceUnavailable, "503 Service Unavailable")
+				return
+			}
+			break
+		}
+		tried[u] = true
+		if lastErr = p.serve(c, u); lastErr == nil {
+			return
+		}
+		if c.Written() || c.Request.Context().Err() != nil { //已经开始写出响应或客户端已经断开，无法重试
+			return
+		}
+	}
+	c.Error(lastErr)
+	c.Fail(http.StatusBadGateway, "502 Bad Gateway")
+}
+
+// serve 将请求转发到指定上游，连接上游失败时返回错误且不写出响应
+func (p *reverseProxy) serve(c *Context, u *upstream) error {
+	var proxyErr error
+	ctx := context.WithValue(c.Request.Context(), proxyErrorKey{}, &proxyErr)
+	req := c.Request.Clone(ctx)
+	req.Header.Set("X-Forwarded-Host", c.Host())
+	req.Header.Set("X-Forwarded-Proto", c.Scheme())
+
+	atomic.AddInt64(&u.active, 1)
+	u.proxy.ServeHTTP(c.Writer, req)
+	atomic.AddInt64(&u.active, -1)
+
+	//客户端取消的请求与上游是否健康无关，不计入失败
+	if c.Request.Context().Err() != nil || errors.Is(proxyErr, context.Canceled) {
+		return proxyErr
+	}
+	u.report(proxyErr == nil && !isUpstreamFailure(c.writer.status), p.config.MaxFails, p.config.FailTimeout)
+	return proxyErr
+}
+
+// pick 按负载均衡策略选择一个没有尝试过的上游
+func (p *reverseProxy) pick(tried map[*upstream]bool) (*upstream, error) {
+	addrs, err := p.discovery.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	all := p.sync(addrs)
+	now := time.Now()
+	var candidates, ejected []*upstream
+	for _, u := range all {
+		if tried[u] {
+			continue
+		}
+		if u.available(now) {
+			candidates = append(candidates, u)
+		} else {
+			ejected = append(ejected, u)
+		}
+	}
+	if len(candidates) == 0 { //全部被摘除时仍然尝试，避免整体不可用
+		candidates = ejected
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("aoiweb: no available upstream")
+	}
+
+	switch p.policy {
+	case Random:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return candidates[p.rand.Intn(len(candidates))], nil
+	case LeastConnections:
+		best := candidates[0]
+		for _, u := range candidates[1:] {
+			if atomic.LoadInt64(&u.active) < atomic.LoadInt64(&best.active) {
+				best = u
+			}
+		}
+		return best, nil
+	default:
+		n := atomic.AddUint64(&p.counter, 1) - 1
+		return candidates[n%uint64(len(candidates))], nil
+	}
+}
+
+// sync 根据最新的地址列表更新上游，已有上游的状态会被保留
+func (p *reverseProxy) sync(addrs []string) []*upstream {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := make([]*upstream, 0, len(addrs))
+	current := make(map[string]*upstream, len(addrs))
+	for _, addr := range addrs {
+		u, ok := p.upstreams[addr]
+		if !ok {
+			target, err := parseUpstream(addr)
+			if err != nil {
+				continue
+			}
+			u = p.newUpstream(target)
+		}
+		current[addr] = u
+		result = append(result, u)
+	}
+	p.upstreams = current
+	return result
+}
+
+func (p *reverseProxy) newUpstream(target *url.URL) *upstream {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		if p.config.Rewrite != nil {
+			p.config.Rewrite(req)
+		}
+	}
+	proxy.Transport = p.config.Transport
+	proxy.ModifyResponse = p.config.ModifyResponse
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		if proxyErr, ok := req.Context().Value(proxyErrorKey{}).(*error); ok {
+			*proxyErr = err
+		}
+	}
+	return &upstream{target: target, proxy: proxy}
+}
+
+// parseUpstream 解析上游地址，支持url与 protocol@addr 两种形式
+func parseUpstream(addr string) (*url.URL, error) {
+	addr = strings.TrimSpace(addr)
+	if !strings.Contains(addr, "://") {
+		scheme := "http"
+		if i := strings.Index(addr, "@"); i >= 0 {
+			if protocol := addr[:i]; protocol == "https" {
+				scheme = protocol
+			}
+			addr = addr[i+1:]
+		}
+		addr = scheme + "://" + addr
+	}
+	return url.Parse(addr)
+}
+
+// isIdempotent 请求方法是否幂等，只有幂等的请求才会重试
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isUpstreamFailure 上游返回的响应码是否表示上游不可用
+func isUpstreamFailure(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
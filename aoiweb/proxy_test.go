@@ -0,0 +1,180 @@
+package aoiweb
+
+import (
+	"AoiFramework/aoirpc/registry"
+	"AoiFramework/aoirpc/xclient"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newBackend(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %s %s", name, r.URL.Path, r.Header.Get("X-Forwarded-Host"), r.Header.Get("X-Rewrite"))
+	}))
+}
+
+func TestProxyRoundRobin(t *testing.T) {
+	a, b := newBackend("a"), newBackend("b")
+	defer a.Close()
+	defer b.Close()
+
+	config := DefaultProxyConfig
+	config.Rewrite = func(req *http.Request) { req.Header.Set("X-Rewrite", "1") }
+	r := New()
+	r.Get("/api/*path", Proxy(xclient.NewMultiServerDiscovery([]string{a.URL, b.URL}), RoundRobin, config))
+
+	var bodies []string
+	for i := 0; i < 4; i++ {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/users", nil)
+		req.Host = "example.com"
+		r.ServeHTTP(w, req)
+		bodies = append(bodies, w.Body.String())
+	}
+	expect := []string{"a /api/users example.com 1", "b /api/users example.com 1"}
+	for i, body := range bodies {
+		if body != expect[i%2] {
+			t.Fatalf("request %d: expect %q, got %q", i, expect[i%2], body)
+		}
+	}
+}
+
+func TestProxyRetryAndEject(t *testing.T) {
+	good := newBackend("good")
+	defer good.Close()
+	bad := httptest.NewServer(http.NotFoundHandler())
+	bad.Close() //连接会被拒绝
+
+	proxy := Proxy(xclient.NewMultiServerDiscovery([]string{bad.URL, good.URL}), RoundRobin,
+		ProxyConfig{MaxFails: 1, FailTimeout: time.Minute, Retries: 1})
+	r := New()
+	r.Any("/*path", proxy)
+
+	for i := 0; i < 4; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/x", nil))
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "good") {
+			t.Fatalf("request %d should be retried on the good upstream, got %d %q", i, w.Code, w.Body.String())
+		}
+	}
+
+	//非幂等请求不重试，坏的上游已被摘除，因此仍然成功
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/x", strings.NewReader("body")))
+	if w.Code != http.StatusOK {
+		t.Fatal("ejected upstream should not be picked, got", w.Code)
+	}
+
+	r = New()
+	r.Any("/*path", Proxy(xclient.NewMultiServerDiscovery([]string{bad.URL}), Random))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/x", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Fatal("unreachable upstream should return 502, got", w.Code)
+	}
+	r = New()
+	r.Any("/*path", Proxy(xclient.NewMultiServerDiscovery(nil), Random))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/x", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatal("no upstream should return 503, got", w.Code)
+	}
+}
+
+func TestProxyLeastConnections(t *testing.T) {
+	p := &reverseProxy{discovery: xclient.NewMultiServerDiscovery([]string{"http@a:1", "https@b:2", "tcp@c:3"}),
+		policy: LeastConnections, upstreams: make(map[string]*upstream)}
+	all := p.sync([]string{"http@a:1", "https@b:2", "tcp@c:3"})
+	if all[0].target.String() != "http://a:1" || all[1].target.String() != "https://b:2" || all[2].target.String() != "http://c:3" {
+		t.Fatal("unexpected upstream targets", all[0].target, all[1].target, all[2].target)
+	}
+	all[0].active, all[1].active, all[2].active = 3, 1, 2
+	if u, _ := p.pick(map[*upstream]bool{}); u != all[1] {
+		t.Fatal("upstream with least connections should be picked, got", u.target)
+	}
+	if u, _ := p.pick(map[*upstream]bool{all[1]: true}); u != all[2] {
+		t.Fatal("tried upstream should be skipped, got", u.target)
+	}
+}
+
+func TestProxyRegistry(t *testing.T) {
+	backend := newBackend("rpc")
+	defer backend.Close()
+	reg := httptest.NewServer(registry.New(0))
+	defer reg.Close()
+	req, _ := http.NewRequest(http.MethodPost, reg.URL, nil)
+	req.Header.Set(registry.AoiKey, "http@"+strings.TrimPrefix(backend.URL, "http://"))
+	if _, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	r.Get("/*path", Proxy(xclient.NewAoiRegistryDiscovery(reg.URL, 0), RoundRobin))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/hello", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "rpc /hello") {
+		t.Fatal("upstream from registry should be used, got", w.Code, w.Body.String())
+	}
+}
+
+func TestProxyWebSocket(t *testing.T) {
+	//上游完成协议升级后原样返回收到的数据
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		rw.Flush()
+		io.Copy(conn, rw)
+	}))
+	defer backend.Close()
+
+	r := New()
+	r.Get("/ws", Proxy(xclient.NewMultiServerDiscovery([]string{backend.URL}), RoundRobin))
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	fmt.Fprint(conn, "GET /ws HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatal("upgrade should be passed through", resp, err)
+	}
+	fmt.Fprint(conn, "ping")
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(reader, buf); err != nil || string(buf) != "ping" {
+		t.Fatal("data should be forwarded after upgrade", string(buf), err)
+	}
+}
+
+func TestProxyClientCanceled(t *testing.T) {
+	backend := newBackend("a")
+	defer backend.Close()
+	p := &reverseProxy{discovery: xclient.NewMultiServerDiscovery([]string{backend.URL}), policy: RoundRobin,
+		config: ProxyConfig{MaxFails: 1, FailTimeout: time.Minute, Retries: 1}, upstreams: make(map[string]*upstream)}
+	r := New()
+	r.Any("/*path", p.handle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/x", nil).WithContext(ctx))
+	if u := p.upstreams[backend.URL]; u == nil || !u.available(time.Now()) {
+		t.Fatal("canceled request should not eject the upstream")
+	}
+}
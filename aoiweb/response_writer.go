@@ -0,0 +1,82 @@
+package aoiweb
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// responseWriter 包装原始的ResponseWriter，记录响应码与写出的字节数
+// 并在首次写出响应头之前执行注册的回调，便于中间件延迟设置响应头
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	written     bool
+	beforeWrite []func() //写出响应头前执行的回调
+}
+
+func (w *responseWriter) reset(writer http.ResponseWriter) {
+	w.ResponseWriter = writer
+	w.status = http.StatusOK
+	w.size = 0
+	w.written = false
+	w.beforeWrite = nil
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.written { //响应头只能写出一次
+		return
+	}
+	//回调中可能继续设置响应头，因此需要先执行
+	for _, fn := range w.beforeWrite {
+		fn()
+	}
+	w.written = true
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+// Flush 实现http.Flusher
+func (w *responseWriter) Flush() {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 实现http.Hijacker，用于websocket等协议升级
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the ResponseWriter doesn't support hijacking")
+	}
+	w.written = true
+	return hijacker.Hijack()
+}
+
+// Push 实现http.Pusher，底层连接不支持服务端推送时返回http.ErrNotSupported
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	pusher, ok := w.ResponseWriter.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return pusher.Push(target, opts)
+}
+
+// Unwrap 返回原始的ResponseWriter，供http.ResponseController使用
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
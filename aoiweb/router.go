@@ -2,12 +2,22 @@ package aoiweb
 
 import (
 	"net/http"
+	"net/url"
 	"strings"
 )
 
 type router struct {
 	roots    map[string]*node      //存储各个请求方式的的树根节点
 	handlers map[string]HandleFunc //存储每种请求方式的处理函数
+	routes   []RouteInfo           //按注册顺序保存的路由信息
+	docs     map[string]*Route     //每条路由的文档信息，key与handlers相同
+}
+
+// RouteInfo 一条已注册的路由
+type RouteInfo struct {
+	Host   string //通过Engine.Host注册时为host规则，默认路由为空
+	Method string
+	Path   string
 }
 
 //newRouter 创建新路由
@@ -15,6 +25,7 @@ func newRouter() *router {
 	return &router{
 		roots:    make(map[string]*node),
 		handlers: make(map[string]HandleFunc),
+		docs:     make(map[string]*Route),
 	}
 }
 
@@ -36,7 +47,7 @@ func parsePattern(pattern string) []string {
 }
 
 //添加路由规则，支持 ：以及* 通配符
-func (r *router) addRoute(method string, pattern string, handler HandleFunc) {
+func (r *router) addRoute(method string, pattern string, handler HandleFunc) *Route {
 	parts := parsePattern(pattern)
 	key := method + "-" + pattern
 	//检查该方法是否又节点存在
@@ -45,22 +56,77 @@ func (r *router) addRoute(method string, pattern string, handler HandleFunc) {
 		r.roots[method] = &node{}
 	}
 	r.roots[method].insert(pattern, parts, 0)
+	if _, ok := r.handlers[key]; !ok {
+		r.routes = append(r.routes, RouteInfo{Method: method, Path: pattern})
+		r.docs[key] = &Route{Method: method, Path: pattern}
+	}
 	r.handlers[key] = handler
+	return r.docs[key]
 }
 
 //真正处理请求的方法
 func (r *router) handle(c *Context) {
+	if e := c.engine; e != nil {
+		if e.UseRawPath && c.Request.URL.RawPath != "" {
+			c.Path = c.Request.URL.RawPath
+		}
+		if e.RemoveExtraSlash {
+			c.Path = cleanPath(c.Path)
+		}
+	}
+	c.router = r
+	//匹配到的处理函数放在中间件之后，中间件可以在匹配结果执行之前中断或修改请求
+	c.routeHandler = r.route(c)
+	c.handlers = append(c.handlers, callRoute)
+	c.Next()
+}
+
+// callRoute 执行当前匹配到的处理函数
+func callRoute(c *Context) {
+	c.routeHandler(c)
+}
+
+//route 匹配路由并设置参数，返回最终执行的处理函数
+//重新匹配时会清除上一次匹配设置的参数与路由规则，只保留host中的参数
+func (r *router) route(c *Context) HandleFunc {
+	c.fullPath = ""
+	c.Params = nil
+	if c.hostParams != nil {
+		c.Params = make(map[string]string, len(c.hostParams))
+		for key, value := range c.hostParams {
+			c.Params[key] = value
+		}
+	}
 	route, m := r.getRoute(c.Method, c.Path)
+	if location, ok := r.fixedPath(c, route); ok {
+		return redirectFixedPath(location)
+	}
+	if route == nil {
+		return notFound
+	}
 	//说明有参数能够进行处理
-	if route != nil {
+	if c.engine != nil && c.engine.UseRawPath && c.engine.UnescapePathValues {
+		for key, value := range m {
+			if unescaped, err := url.PathUnescape(value); err == nil {
+				m[key] = unescaped
+			}
+		}
+	}
+	if c.Params == nil {
 		c.Params = m
-		key := c.Method + "-" + route.pattern //获取对应路由的key
-		handleFunc := r.handlers[key]
-		c.handlers = append(c.handlers, handleFunc)
-	} else {
-		c.String(http.StatusNotFound, "404 NOT FOUND %s \n", c.Path)
+	} else { //合并host中的参数
+		for key, value := range m {
+			c.Params[key] = value
+		}
 	}
-	c.Next()
+	c.fullPath = route.pattern
+	key := c.Method + "-" + route.pattern //获取对应路由的key
+	return r.handlers[key]
+}
+
+//notFound 没有匹配到路由时的处理函数
+func notFound(c *Context) {
+	c.String(http.StatusNotFound, "404 NOT FOUND %s \n", c.Path)
 }
 
 func (r *router) getRoute(method string, path string) (*node, map[string]string) {
@@ -0,0 +1,178 @@
+package aoiweb
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const cspNonceKey = "aoiweb/csp-nonce"
+
+// SecureConfig 安全响应头中间件的配置，字段为空时不设置对应的响应头
+type SecureConfig struct {
+	AllowedHosts      []string   //允许的host列表，为空时不检查
+	HostsProxyHeaders []string   //代理转发原始host时使用的请求头，如 X-Forwarded-Host，只信任受信任代理设置的值
+	BadHostHandler    HandleFunc //host不在允许列表时的处理函数，默认返回400
+
+	SSLRedirect          bool              //将http请求重定向到https
+	SSLTemporaryRedirect bool              //使用临时重定向(302/307)而不是永久重定向(301/308)
+	SSLHost              string            //重定向使用的host，为空时使用请求的host
+	SSLProxyHeaders      map[string]string //代理终止tls时用于判断原始协议的请求头，如 X-Forwarded-Proto: https，只信任受信任代理设置的值
+
+	STSSeconds           int64 //Strict-Transport-Security的max-age，为0时不设置
+	STSIncludeSubdomains bool
+	STSPreload           bool
+	ForceSTSHeader       bool //非https请求也设置HSTS
+
+	FrameOptions          string //X-Frame-Options，如 DENY、SAMEORIGIN
+	ContentTypeNosniff    bool   //设置 X-Content-Type-Options: nosniff
+	ContentSecurityPolicy string //可以使用 {nonce} 占位，每个请求替换为随机值
+	ReferrerPolicy        string
+	PermissionsPolicy     string
+}
+
+var DefaultSecureConfig = SecureConfig{
+	STSSeconds:            31536000,
+	STSIncludeSubdomains:  true,
+	FrameOptions:          "DENY",
+	ContentTypeNosniff:    true,
+	ContentSecurityPolicy: "default-src 'self'; script-src 'self' 'nonce-{nonce}'",
+	ReferrerPolicy:        "strict-origin-when-cross-origin",
+}
+
+// Secure 设置常用的安全响应头，可选地将http重定向到https并限制允许访问的host
+// CSP中的nonce可以在模板中通过 {{ cspNonce }} 获取
+func Secure(config SecureConfig) HandleFunc {
+	badHostHandler := config.BadHostHandler
+	if badHostHandler == nil {
+		badHostHandler = func(c *Context) {
+			c.Fail(http.StatusBadRequest, "Bad Host")
+		}
+	}
+	sts := ""
+	if config.STSSeconds > 0 {
+		sts = fmt.Sprintf("max-age=%d", config.STSSeconds)
+		if config.STSIncludeSubdomains {
+			sts += "; includeSubDomains"
+		}
+		if config.STSPreload {
+			sts += "; preload"
+		}
+	}
+	return func(c *Context) {
+		host := config.host(c)
+		if len(config.AllowedHosts) > 0 && !config.allowHost(host) {
+			badHostHandler(c)
+			c.Abort()
+			return
+		}
+
+		https := config.isHTTPS(c)
+		if config.SSLRedirect && !https {
+			if config.SSLHost != "" {
+				host = config.SSLHost
+			}
+			c.Redirect(redirectCode(c.Method, !config.SSLTemporaryRedirect), "https://"+host+c.Request.URL.RequestURI())
+			c.Abort()
+			return
+		}
+
+		header := c.Writer.Header()
+		if sts != "" && (https || config.ForceSTSHeader) {
+			header.Set("Strict-Transport-Security", sts)
+		}
+		if config.FrameOptions != "" {
+			header.Set("X-Frame-Options", config.FrameOptions)
+		}
+		if config.ContentTypeNosniff {
+			header.Set("X-Content-Type-Options", "nosniff")
+		}
+		if config.ContentSecurityPolicy != "" {
+			policy := config.ContentSecurityPolicy
+			if strings.Contains(policy, "{nonce}") {
+				nonce := newNonce()
+				c.Set(cspNonceKey, nonce)
+				c.setTemplateFunc("cspNonce", func() string { return nonce })
+				policy = strings.ReplaceAll(policy, "{nonce}", nonce)
+			}
+			header.Set("Content-Security-Policy", policy)
+		}
+		if config.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", config.ReferrerPolicy)
+		}
+		if config.PermissionsPolicy != "" {
+			header.Set("Permissions-Policy", config.PermissionsPolicy)
+		}
+		c.Next()
+	}
+}
+
+// CSPNonce 返回当前请求CSP中使用的nonce
+func (c *Context) CSPNonce() string {
+	nonce, _ := c.Get(cspNonceKey)
+	s, _ := nonce.(string)
+	return s
+}
+
+// redirectCode GET与HEAD请求使用301/302，其余请求使用308/307以保留请求方法与请求体
+func redirectCode(method string, permanent bool) int {
+	get := method == http.MethodGet || method == http.MethodHead
+	switch {
+	case permanent && get:
+		return http.StatusMovedPermanently
+	case permanent:
+		return http.StatusPermanentRedirect
+	case get:
+		return http.StatusFound
+	}
+	return http.StatusTemporaryRedirect
+}
+
+// host 返回客户端请求的host，请求来自受信任的代理时优先使用配置的代理请求头
+// 客户端直接连接时忽略这些请求头，避免伪造host绕过AllowedHosts
+func (config *SecureConfig) host(c *Context) string {
+	if !c.fromTrustedProxy() {
+		return c.Host()
+	}
+	for _, name := range config.HostsProxyHeaders {
+		if host := c.Request.Header.Get(name); host != "" {
+			return host
+		}
+	}
+	return c.Host()
+}
+
+func (config *SecureConfig) allowHost(host string) bool {
+	for _, allowed := range config.AllowedHosts {
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHTTPS 判断请求是否使用https，SSLProxyHeaders与host相同，只在请求来自受信任的代理时使用
+func (config *SecureConfig) isHTTPS(c *Context) bool {
+	if c.Scheme() == "https" {
+		return true
+	}
+	if !c.fromTrustedProxy() {
+		return false
+	}
+	for name, value := range config.SSLProxyHeaders {
+		if strings.EqualFold(c.Request.Header.Get(name), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
@@ -0,0 +1,86 @@
+package aoiweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSecureHeaders(t *testing.T) {
+	r := New()
+	r.Use(Secure(DefaultSecureConfig))
+	r.Get("/", func(c *Context) {
+		c.String(http.StatusOK, "%s", c.CSPNonce())
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	r.ServeHTTP(w, req)
+	header := w.Header()
+	if header.Get("X-Frame-Options") != "DENY" || header.Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatal("missing security headers", header)
+	}
+	if header.Get("Strict-Transport-Security") != "" {
+		t.Fatal("hsts should only be sent over https")
+	}
+	nonce := w.Body.String()
+	if nonce == "" || !strings.Contains(header.Get("Content-Security-Policy"), "'nonce-"+nonce+"'") {
+		t.Fatal("csp should contain request nonce, got", header.Get("Content-Security-Policy"))
+	}
+}
+
+func TestSecureRedirectAndHosts(t *testing.T) {
+	r := New()
+	_ = r.SetTrustedProxies([]string{"10.0.0.1"})
+	r.Use(Secure(SecureConfig{
+		AllowedHosts:      []string{"example.com"},
+		HostsProxyHeaders: []string{"X-Original-Host"},
+		SSLRedirect:       true,
+		SSLProxyHeaders:   map[string]string{"X-Forwarded-Proto": "https"},
+		STSSeconds:        60,
+	}))
+	r.Get("/a", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	r.Post("/a", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	const proxy, client = "10.0.0.1:1234", "192.0.2.1:1234"
+	tests := []struct {
+		method, remote, host, proxyHost, proto string
+		code                                   int
+	}{
+		{"GET", proxy, "example.com", "", "", http.StatusMovedPermanently},
+		{"POST", proxy, "example.com", "", "", http.StatusPermanentRedirect},
+		{"GET", proxy, "example.com", "", "https", http.StatusOK},
+		{"GET", proxy, "evil.com", "", "https", http.StatusBadRequest},
+		{"GET", proxy, "internal", "example.com", "https", http.StatusOK},
+		//客户端直接连接时不信任转发头
+		{"GET", client, "example.com", "", "https", http.StatusMovedPermanently},
+		{"GET", client, "evil.com", "example.com", "https", http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(test.method, "/a?x=1", nil)
+		req.RemoteAddr = test.remote
+		req.Host = test.host
+		if test.proxyHost != "" {
+			req.Header.Set("X-Original-Host", test.proxyHost)
+		}
+		if test.proto != "" {
+			req.Header.Set("X-Forwarded-Proto", test.proto)
+		}
+		r.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Fatalf("%s %s from %s: expect %d, got %d", test.method, test.host, test.remote, test.code, w.Code)
+		}
+		if w.Code == http.StatusMovedPermanently && w.Header().Get("Location") != "https://example.com/a?x=1" {
+			t.Fatal("unexpected redirect location", w.Header().Get("Location"))
+		}
+		if w.Code == http.StatusOK && w.Header().Get("Strict-Transport-Security") != "max-age=60" {
+			t.Fatal("hsts should be sent behind tls proxy")
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package aoiweb
+
+import (
+	"log"
+	"net/http"
+)
+
+const (
+	sessionStoreKey = "aoiweb/session-store" //上下文中保存store的键
+	sessionKey      = "aoiweb/session"       //上下文中保存已加载session的键
+	flashKey        = "_flash"               //默认的闪存消息键
+)
+
+// SessionOptions 写出session cookie时使用的属性
+type SessionOptions struct {
+	Name     string //cookie名称
+	Path     string
+	Domain   string
+	MaxAge   int //小于0时删除session
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+var DefaultSessionOptions = SessionOptions{
+	Name:     "aoisession",
+	Path:     "/",
+	MaxAge:   86400 * 7,
+	HttpOnly: true,
+	SameSite: http.SameSiteLaxMode,
+}
+
+// Store session的存储方式，可以是客户端cookie也可以是服务端存储
+type Store interface {
+	// Get 从请求中加载session，不存在时返回新建的session
+	Get(c *Context) (*Session, error)
+	// Save 保存session，并向响应中写出cookie
+	Save(c *Context, session *Session) error
+}
+
+// Session 一次请求中的会话数据
+type Session struct {
+	ID       string                 //服务端存储时使用的id，cookie存储时为空
+	Values   map[string]interface{} //会话数据，保存时编码为json
+	Options  SessionOptions
+	IsNew    bool //是否为本次请求新建
+	modified bool //只有修改过的session才会被保存
+}
+
+func newSession(options SessionOptions) *Session {
+	return &Session{
+		Values:  make(map[string]interface{}),
+		Options: options,
+		IsNew:   true,
+	}
+}
+
+// Get 获取会话数据
+func (s *Session) Get(key string) interface{} {
+	return s.Values[key]
+}
+
+// Set 设置会话数据
+func (s *Session) Set(key string, value interface{}) {
+	s.Values[key] = value
+	s.modified = true
+}
+
+// Delete 删除会话数据
+func (s *Session) Delete(key string) {
+	delete(s.Values, key)
+	s.modified = true
+}
+
+// Clear 清空所有会话数据
+func (s *Session) Clear() {
+	for key := range s.Values {
+		delete(s.Values, key)
+	}
+	s.modified = true
+}
+
+// Destroy 清空会话数据并在保存时删除cookie
+func (s *Session) Destroy() {
+	s.Clear()
+	s.Options.MaxAge = -1
+}
+
+// AddFlash 添加一条闪存消息，读取一次后即被删除
+func (s *Session) AddFlash(value interface{}, vars ...string) {
+	key := flashKey
+	if len(vars) > 0 {
+		key = vars[0]
+	}
+	flashes, _ := s.Values[key].([]interface{})
+	s.Values[key] = append(flashes, value)
+	s.modified = true
+}
+
+// Flashes 读取并删除闪存消息
+func (s *Session) Flashes(vars ...string) []interface{} {
+	key := flashKey
+	if len(vars) > 0 {
+		key = vars[0]
+	}
+	flashes, ok := s.Values[key].([]interface{})
+	if ok {
+		delete(s.Values, key)
+		s.modified = true
+	}
+	return flashes
+}
+
+// Modified 返回session是否被修改过
+func (s *Session) Modified() bool {
+	return s.modified
+}
+
+// Sessions 提供session中间件，session在首次调用Context.Session时加载
+// 只有被修改过的session才会在写出响应头之前保存
+func Sessions(store Store) HandleFunc {
+	return func(c *Context) {
+		c.Set(sessionStoreKey, store)
+		c.Next()
+		//处理函数没有写出任何数据时，响应头尚未发送，仍然可以保存
+		if !c.Written() {
+			c.saveSession()
+		}
+	}
+}
+
+// Session 返回当前请求的session，需要先使用Sessions中间件
+func (c *Context) Session() *Session {
+	if s, ok := c.Get(sessionKey); ok {
+		return s.(*Session)
+	}
+	store := c.MustGet(sessionStoreKey).(Store)
+	s, err := store.Get(c)
+	if err != nil {
+		//cookie被篡改或已过期，直接使用新的session
+		log.Println("[session] load failed:", err)
+	}
+	c.Set(sessionKey, s)
+	c.beforeWrite(c.saveSession)
+	return s
+}
+
+// saveSession 保存修改过的session，只会执行一次
+func (c *Context) saveSession() {
+	value, ok := c.Get(sessionKey)
+	if !ok {
+		return
+	}
+	s := value.(*Session)
+	if !s.modified {
+		return
+	}
+	s.modified = false
+	store := c.MustGet(sessionStoreKey).(Store)
+	if err := store.Save(c, s); err != nil {
+		log.Println("[session] save failed:", err)
+	}
+}
@@ -0,0 +1,272 @@
+package aoiweb
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errInvalidSignature = errors.New("session: invalid cookie signature")
+	errExpiredCookie    = errors.New("session: cookie expired")
+)
+
+// signer 使用HMAC-SHA256对cookie签名，支持密钥轮换：
+// 使用第一个密钥签名，所有密钥都可以用于校验
+type signer struct {
+	keys [][]byte
+}
+
+func newSigner(keys [][]byte) signer {
+	if len(keys) == 0 {
+		panic("session: at least one key is required")
+	}
+	return signer{keys: keys}
+}
+
+func (s signer) mac(key []byte, name, payload string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name + "|" + payload))
+	return h.Sum(nil)
+}
+
+// sign 编码格式为 base64(value)|timestamp|base64(mac)
+func (s signer) sign(name string, value []byte) string {
+	payload := base64.RawURLEncoding.EncodeToString(value) + "|" + strconv.FormatInt(time.Now().Unix(), 10)
+	mac := s.mac(s.keys[0], name, payload)
+	return payload + "|" + base64.RawURLEncoding.EncodeToString(mac)
+}
+
+// verify 校验签名与有效期，maxAge小于等于0时不检查有效期
+func (s signer) verify(name, signed string, maxAge int) ([]byte, error) {
+	index := strings.LastIndex(signed, "|")
+	if index < 0 {
+		return nil, errInvalidSignature
+	}
+	payload := signed[:index]
+	mac, err := base64.RawURLEncoding.DecodeString(signed[index+1:])
+	if err != nil {
+		return nil, errInvalidSignature
+	}
+	valid := false
+	for _, key := range s.keys {
+		if hmac.Equal(mac, s.mac(key, name, payload)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, errInvalidSignature
+	}
+	parts := strings.SplitN(payload, "|", 2)
+	if len(parts) != 2 {
+		return nil, errInvalidSignature
+	}
+	timestamp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, errInvalidSignature
+	}
+	if maxAge > 0 && time.Now().Unix()-timestamp > int64(maxAge) {
+		return nil, errExpiredCookie
+	}
+	return base64.RawURLEncoding.DecodeString(parts[0])
+}
+
+// writeSessionCookie 按照session的属性写出cookie
+func writeSessionCookie(c *Context, options SessionOptions, value string) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     options.Name,
+		Value:    value,
+		Path:     options.Path,
+		Domain:   options.Domain,
+		MaxAge:   options.MaxAge,
+		Secure:   options.Secure,
+		HttpOnly: options.HttpOnly,
+		SameSite: options.SameSite,
+	})
+}
+
+// CookieStore 将session数据签名后直接保存在cookie中
+type CookieStore struct {
+	Options SessionOptions
+	signer  signer
+}
+
+// NewCookieStore 传入签名密钥，第一个密钥用于签名，其余密钥用于校验轮换前签发的cookie
+func NewCookieStore(keys ...[]byte) *CookieStore {
+	return &CookieStore{
+		Options: DefaultSessionOptions,
+		signer:  newSigner(keys),
+	}
+}
+
+func (s *CookieStore) Get(c *Context) (*Session, error) {
+	session := newSession(s.Options)
+	value, err := c.Cookie(s.Options.Name)
+	if err != nil {
+		return session, nil //没有cookie，返回新的session
+	}
+	data, err := s.signer.verify(s.Options.Name, value, s.Options.MaxAge)
+	if err != nil {
+		return session, err
+	}
+	if err = json.Unmarshal(data, &session.Values); err != nil {
+		return session, err
+	}
+	session.IsNew = false
+	return session, nil
+}
+
+func (s *CookieStore) Save(c *Context, session *Session) error {
+	if session.Options.MaxAge < 0 {
+		writeSessionCookie(c, session.Options, "")
+		return nil
+	}
+	data, err := json.Marshal(session.Values)
+	if err != nil {
+		return err
+	}
+	signed := s.signer.sign(session.Options.Name, data)
+	if len(signed) > 4096 {
+		return fmt.Errorf("session: cookie value too long (%d bytes)", len(signed))
+	}
+	writeSessionCookie(c, session.Options, signed)
+	return nil
+}
+
+// SessionBackend 服务端session的存储后端，保存序列化后的会话数据
+type SessionBackend interface {
+	// Load 读取数据，不存在或已过期时返回nil
+	Load(id string) ([]byte, error)
+	// Save 保存数据，ttl为0时永不过期
+	Save(id string, data []byte, ttl time.Duration) error
+	// Delete 删除数据
+	Delete(id string) error
+}
+
+// ServerStore 会话数据保存在服务端，cookie中只保存签名后的session id
+type ServerStore struct {
+	Options SessionOptions
+	backend SessionBackend
+	signer  signer
+}
+
+// NewServerStore 传入存储后端与签名密钥
+func NewServerStore(backend SessionBackend, keys ...[]byte) *ServerStore {
+	return &ServerStore{
+		Options: DefaultSessionOptions,
+		backend: backend,
+		signer:  newSigner(keys),
+	}
+}
+
+func (s *ServerStore) Get(c *Context) (*Session, error) {
+	session := newSession(s.Options)
+	value, err := c.Cookie(s.Options.Name)
+	if err != nil {
+		return session, nil
+	}
+	id, err := s.signer.verify(s.Options.Name, value, s.Options.MaxAge)
+	if err != nil {
+		return session, err
+	}
+	data, err := s.backend.Load(string(id))
+	if err != nil || data == nil {
+		return session, err //后端中已经过期
+	}
+	if err = json.Unmarshal(data, &session.Values); err != nil {
+		return session, err
+	}
+	session.ID = string(id)
+	session.IsNew = false
+	return session, nil
+}
+
+func (s *ServerStore) Save(c *Context, session *Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.backend.Delete(session.ID); err != nil {
+				return err
+			}
+		}
+		writeSessionCookie(c, session.Options, "")
+		return nil
+	}
+	if session.ID == "" {
+		session.ID = newSessionID()
+	}
+	data, err := json.Marshal(session.Values)
+	if err != nil {
+		return err
+	}
+	ttl := time.Duration(session.Options.MaxAge) * time.Second
+	if err = s.backend.Save(session.ID, data, ttl); err != nil {
+		return err
+	}
+	writeSessionCookie(c, session.Options, s.signer.sign(session.Options.Name, []byte(session.ID)))
+	return nil
+}
+
+func newSessionID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+type memoryItem struct {
+	data     []byte
+	expireAt time.Time //零值表示永不过期
+}
+
+// MemoryBackend 基于内存的session存储后端，仅适用于单机部署
+type MemoryBackend struct {
+	mu    sync.Mutex
+	items map[string]memoryItem
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{items: make(map[string]memoryItem)}
+}
+
+func (m *MemoryBackend) Load(id string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[id]
+	if !ok {
+		return nil, nil
+	}
+	if !item.expireAt.IsZero() && item.expireAt.Before(time.Now()) {
+		delete(m.items, id) //惰性删除过期数据
+		return nil, nil
+	}
+	return item.data, nil
+}
+
+func (m *MemoryBackend) Save(id string, data []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item := memoryItem{data: data}
+	if ttl > 0 {
+		item.expireAt = time.Now().Add(ttl)
+	}
+	m.items[id] = item
+	return nil
+}
+
+func (m *MemoryBackend) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, id)
+	return nil
+}
@@ -0,0 +1,82 @@
+package aoiweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newSessionEngine(store Store) *Engine {
+	r := New()
+	r.Use(Sessions(store))
+	r.Get("/login", func(c *Context) {
+		s := c.Session()
+		s.Set("user", "Tom")
+		s.AddFlash("welcome")
+		c.String(http.StatusOK, "ok")
+	})
+	r.Get("/me", func(c *Context) {
+		s := c.Session()
+		flashes := s.Flashes()
+		c.String(http.StatusOK, "%v %v", s.Get("user"), flashes)
+	})
+	r.Get("/noop", func(c *Context) {
+		c.String(http.StatusOK, "%v", c.Session().Get("user"))
+	})
+	return r
+}
+
+func doWithCookies(r *Engine, path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func testSessionStore(t *testing.T, store Store) {
+	r := newSessionEngine(store)
+	w := doWithCookies(r, "/login", nil)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "aoisession" || !cookies[0].HttpOnly {
+		t.Fatal("login should set session cookie, got", cookies)
+	}
+
+	w = doWithCookies(r, "/me", cookies)
+	if w.Body.String() != "Tom [welcome]" {
+		t.Fatal("unexpected session values", w.Body.String())
+	}
+	//读取闪存消息修改了session，需要重新保存
+	if len(w.Result().Cookies()) != 1 {
+		t.Fatal("reading flashes should save session")
+	}
+
+	w = doWithCookies(r, "/noop", w.Result().Cookies())
+	if w.Body.String() != "Tom" || len(w.Result().Cookies()) != 0 {
+		t.Fatal("unmodified session should not be saved")
+	}
+}
+
+func TestCookieStore(t *testing.T) {
+	testSessionStore(t, NewCookieStore([]byte("secret")))
+}
+
+func TestServerStore(t *testing.T) {
+	testSessionStore(t, NewServerStore(NewMemoryBackend(), []byte("secret")))
+}
+
+func TestCookieStoreKeyRotation(t *testing.T) {
+	w := doWithCookies(newSessionEngine(NewCookieStore([]byte("old"))), "/login", nil)
+	cookies := w.Result().Cookies()
+
+	w = doWithCookies(newSessionEngine(NewCookieStore([]byte("new"), []byte("old"))), "/noop", cookies)
+	if w.Body.String() != "Tom" {
+		t.Fatal("cookie signed by old key should be accepted, got", w.Body.String())
+	}
+	w = doWithCookies(newSessionEngine(NewCookieStore([]byte("new"))), "/noop", cookies)
+	if w.Body.String() != "<nil>" {
+		t.Fatal("cookie signed by removed key should be rejected, got", w.Body.String())
+	}
+}
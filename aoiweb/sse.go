@@ -0,0 +1,118 @@
+package aoiweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Event 一条 Server-Sent Events 消息
+type Event struct {
+	Id    string      //事件id，客户端重连时通过 Last-Event-ID 带回
+	Event string      //事件名称，为空时客户端按 message 处理
+	Retry uint        //客户端重连间隔，单位毫秒，为0时不发送
+	Data  interface{} //数据，字符串原样输出，其余类型编码为json
+}
+
+//writeTo 按照 text/event-stream 格式写出事件
+func (e Event) writeTo(w io.Writer) error {
+	var builder strings.Builder
+	if e.Id != "" {
+		builder.WriteString("id: " + escapeField(e.Id) + "\n")
+	}
+	if e.Event != "" {
+		builder.WriteString("event: " + escapeField(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		builder.WriteString(fmt.Sprintf("retry: %d\n", e.Retry))
+	}
+	data, err := encodeData(e.Data)
+	if err != nil {
+		return err
+	}
+	//多行数据需要拆分为多个data字段
+	for _, line := range strings.Split(data, "\n") {
+		builder.WriteString("data: " + line + "\n")
+	}
+	builder.WriteString("\n")
+	_, err = io.WriteString(w, builder.String())
+	return err
+}
+
+//escapeField id与event中不允许出现换行
+func escapeField(s string) string {
+	return strings.NewReplacer("\n", "", "\r", "").Replace(s)
+}
+
+func encodeData(data interface{}) (string, error) {
+	switch v := data.(type) {
+	case string:
+		return strings.ReplaceAll(v, "\r\n", "\n"), nil
+	case []byte:
+		return strings.ReplaceAll(string(v), "\r\n", "\n"), nil
+	}
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+//prepareStream 首次写出事件前设置响应头
+func (c *Context) prepareStream() {
+	if c.Written() {
+		return //已经写出过响应头
+	}
+	c.SetHeader("Content-Type", "text/event-stream")
+	c.SetHeader("Cache-Control", "no-cache")
+	c.SetHeader("Connection", "keep-alive")
+	c.SetHeader("X-Accel-Buffering", "no") //避免nginx缓冲
+	c.Status(http.StatusOK)
+}
+
+// SSEvent 发送一条带名称的事件并立即刷新
+func (c *Context) SSEvent(name string, data interface{}) {
+	c.SSE(Event{Event: name, Data: data})
+}
+
+// SSE 发送完整的事件，支持 id 与 retry 字段
+func (c *Context) SSE(event Event) {
+	c.prepareStream()
+	if err := event.writeTo(c.Writer); err != nil {
+		panic(err)
+	}
+	c.Flush()
+}
+
+// Flush 将已写出的数据立即推送到客户端
+func (c *Context) Flush() {
+	if flusher, ok := c.Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// LastEventID 返回客户端重连时携带的最后事件id
+func (c *Context) LastEventID() string {
+	return c.Request.Header.Get("Last-Event-ID")
+}
+
+// Stream 不断调用step写出数据，step返回false或客户端断开连接时结束
+// 返回值表示是否因客户端断开而结束
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	c.prepareStream()
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+			keepOpen := step(c.Writer)
+			c.Flush()
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package aoiweb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSSEvent(t *testing.T) {
+	r := New()
+	r.Use(Logger(), Recovery())
+	r.Get("/events", func(c *Context) {
+		c.SSE(Event{Id: c.LastEventID() + "1", Retry: 3000, Data: "first\nline"})
+		c.SSEvent("progress", H{"percent": 50})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events", nil)
+	req.Header.Set("Last-Event-ID", "4")
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatal("content type should be text/event-stream, got", w.Header().Get("Content-Type"))
+	}
+	expect := "id: 41\nretry: 3000\ndata: first\ndata: line\n\n" +
+		"event: progress\ndata: {\"percent\":50}\n\n"
+	if w.Body.String() != expect {
+		t.Fatalf("unexpected stream body %q", w.Body.String())
+	}
+	if !w.Flushed {
+		t.Fatal("events should be flushed")
+	}
+}
+
+func TestStreamStopsOnDisconnect(t *testing.T) {
+	r := New()
+	steps := 0
+	r.Get("/stream", func(c *Context) {
+		c.Stream(func(w io.Writer) bool {
+			steps++
+			io.WriteString(w, "data: tick\n\n")
+			return steps < 3
+		})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/stream", nil)
+	r.ServeHTTP(w, req)
+	if steps != 3 {
+		t.Fatal("stream should stop when step returns false, got steps", steps)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	steps = 0
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequestWithContext(ctx, "GET", "/stream", nil)
+	r.ServeHTTP(w, req)
+	if steps != 0 {
+		t.Fatal("stream should stop when client is gone, got steps", steps)
+	}
+}
@@ -0,0 +1,314 @@
+// Package metrics 提供一个不依赖第三方库的指标注册表，
+// 支持counter、gauge与histogram，并以Prometheus文本格式输出
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	counterType   = "counter"
+	gaugeType     = "gauge"
+	histogramType = "histogram"
+)
+
+// DefBuckets 默认的histogram分桶，单位为秒，适用于请求耗时
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry 保存所有注册的指标
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]*metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*metric)}
+}
+
+// DefaultRegistry 默认的注册表，aoiweb、aoicache与aoirpc没有指定注册表时使用
+var DefaultRegistry = NewRegistry()
+
+// register 注册指标，同名指标的类型、标签与分桶都相同时返回已注册的指标，定义不同时panic
+// 因此各个包的指标可以在同一个注册表中重复注册而共用同一组数据
+func (r *Registry) register(m *metric) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.metrics[m.name]; ok {
+		if !existing.sameDefinition(m) {
+			panic("metrics: duplicate metric " + m.name)
+		}
+		return existing
+	}
+	r.metrics[m.name] = m
+	return m
+}
+
+// WriteTo 按名称排序，以Prometheus文本格式写出所有指标
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	metrics := make([]*metric, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		metrics = append(metrics, r.metrics[name])
+	}
+	r.mu.Unlock()
+
+	counter := &countingWriter{w: bufio.NewWriter(w)}
+	for _, m := range metrics {
+		m.writeTo(counter)
+	}
+	err := counter.w.Flush()
+	if counter.err != nil {
+		err = counter.err
+	}
+	return counter.n, err
+}
+
+// ServeHTTP 实现http.Handler，可以直接挂载到 /metrics
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) printf(format string, args ...interface{}) {
+	if c.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(c.w, format, args...)
+	c.n += int64(n)
+	c.err = err
+}
+
+// metric 一个指标及其所有标签组合
+type metric struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+	buckets    []float64 //只有histogram使用
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series 一组标签值对应的数据
+type series struct {
+	labelValues []string
+	value       float64  //counter与gauge的值
+	counts      []uint64 //histogram每个分桶的计数，不累加
+	count       uint64
+	sum         float64
+}
+
+func newMetric(name, help, typ string, labelNames []string) *metric {
+	return &metric{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		series:     make(map[string]*series),
+	}
+}
+
+// sameDefinition 判断两个指标的类型、标签名称与分桶是否相同
+func (m *metric) sameDefinition(other *metric) bool {
+	if m.typ != other.typ || len(m.labelNames) != len(other.labelNames) || len(m.buckets) != len(other.buckets) {
+		return false
+	}
+	for i := range m.labelNames {
+		if m.labelNames[i] != other.labelNames[i] {
+			return false
+		}
+	}
+	for i := range m.buckets {
+		if m.buckets[i] != other.buckets[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// with 返回标签值对应的数据，调用方需要持有锁
+func (m *metric) with(labelValues []string) *series {
+	if len(labelValues) != len(m.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", m.name, len(m.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if m.typ == histogramType {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+func (m *metric) add(delta float64, labelValues []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.with(labelValues).value += delta
+}
+
+func (m *metric) set(value float64, labelValues []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.with(labelValues).value = value
+}
+
+func (m *metric) writeTo(w *countingWriter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	w.printf("# HELP %s %s\n", m.name, escapeHelp(m.help))
+	w.printf("# TYPE %s %s\n", m.name, m.typ)
+	for _, key := range keys {
+		s := m.series[key]
+		if m.typ != histogramType {
+			w.printf("%s%s %s\n", m.name, m.labels(s.labelValues, ""), formatFloat(s.value))
+			continue
+		}
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += s.counts[i]
+			w.printf("%s_bucket%s %d\n", m.name, m.labels(s.labelValues, formatFloat(bound)), cumulative)
+		}
+		w.printf("%s_bucket%s %d\n", m.name, m.labels(s.labelValues, "+Inf"), s.count)
+		w.printf("%s_sum%s %s\n", m.name, m.labels(s.labelValues, ""), formatFloat(s.sum))
+		w.printf("%s_count%s %d\n", m.name, m.labels(s.labelValues, ""), s.count)
+	}
+}
+
+// labels 生成 {a="1",b="2"} 形式的标签，le不为空时追加histogram的分桶标签
+func (m *metric) labels(values []string, le string) string {
+	if len(values) == 0 && le == "" {
+		return ""
+	}
+	pairs := make([]string, 0, len(values)+1)
+	for i, value := range values {
+		pairs = append(pairs, m.labelNames[i]+`="`+escapeLabel(value)+`"`)
+	}
+	if le != "" {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Counter 只增不减的计数器
+type Counter struct{ m *metric }
+
+// NewCounter 注册一个counter，labelNames为标签名称，已经注册过相同的counter时返回已有的
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	return &Counter{m: r.register(newMetric(name, help, counterType, labelNames))}
+}
+
+// Inc 计数加一，labelValues与注册时的标签名称一一对应
+func (c *Counter) Inc(labelValues ...string) {
+	c.m.add(1, labelValues)
+}
+
+// Add 计数增加delta，delta不能为负数
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.m.add(delta, labelValues)
+}
+
+// Gauge 可增可减的瞬时值
+type Gauge struct{ m *metric }
+
+// NewGauge 注册一个gauge，已经注册过相同的gauge时返回已有的
+func (r *Registry) NewGauge(name, help string, labelNames ...string) *Gauge {
+	return &Gauge{m: r.register(newMetric(name, help, gaugeType, labelNames))}
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) { g.m.set(value, labelValues) }
+func (g *Gauge) Inc(labelValues ...string)                { g.m.add(1, labelValues) }
+func (g *Gauge) Dec(labelValues ...string)                { g.m.add(-1, labelValues) }
+func (g *Gauge) Add(delta float64, labelValues ...string) { g.m.add(delta, labelValues) }
+
+// Histogram 按分桶统计观测值的分布
+type Histogram struct{ m *metric }
+
+// NewHistogram 注册一个histogram，buckets为空时使用DefBuckets，已经注册过相同的histogram时返回已有的
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	m := newMetric(name, help, histogramType, labelNames)
+	m.buckets = buckets
+	return &Histogram{m: r.register(m)}
+}
+
+// Observe 记录一个观测值
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+	s := h.m.with(labelValues)
+	//找到第一个上界不小于value的分桶，超出所有分桶的值只计入+Inf
+	if i := sort.SearchFloat64s(h.m.buckets, value); i < len(s.counts) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+// NewCounter 在DefaultRegistry中注册counter
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	return DefaultRegistry.NewCounter(name, help, labelNames...)
+}
+
+// NewGauge 在DefaultRegistry中注册gauge
+func NewGauge(name, help string, labelNames ...string) *Gauge {
+	return DefaultRegistry.NewGauge(name, help, labelNames...)
+}
+
+// NewHistogram 在DefaultRegistry中注册histogram
+func NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets, labelNames...)
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWriteTo(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("requests_total", "Total requests.", "code")
+	inFlight := r.NewGauge("in_flight", "In flight requests.")
+	latency := r.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1})
+
+	requests.Inc("200")
+	requests.Add(2, "200")
+	requests.Inc(`5"00`)
+	inFlight.Inc()
+	inFlight.Inc()
+	inFlight.Dec()
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+	latency.Observe(3)
+
+	var builder strings.Builder
+	if _, err := r.WriteTo(&builder); err != nil {
+		t.Fatal(err)
+	}
+	expect := `# HELP in_flight In flight requests.
+# TYPE in_flight gauge
+in_flight 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 3.55
+latency_seconds_count 3
+# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{code="200"} 3
+requests_total{code="5\"00"} 1
+`
+	if builder.String() != expect {
+		t.Fatalf("unexpected output:\n%s", builder.String())
+	}
+}
+
+func TestDuplicateMetric(t *testing.T) {
+	r := NewRegistry()
+	a, b := r.NewCounter("dup", "", "code"), r.NewCounter("dup", "", "code")
+	a.Inc("200")
+	b.Inc("200")
+	var builder strings.Builder
+	_, _ = r.WriteTo(&builder)
+	if !strings.Contains(builder.String(), `dup{code="200"} 2`) {
+		t.Fatal("same counter registered twice should share data, got", builder.String())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicate metric with a different definition should panic")
+		}
+	}()
+	r.NewGauge("dup", "")
+}